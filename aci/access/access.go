@@ -0,0 +1,261 @@
+// Package access resolves access policy chains from leaf interfaces through
+// interface policy groups, AEPs and domains to VLAN pools.
+package access
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Range is an inclusive VLAN range.
+type Range struct {
+	From int
+	To   int
+}
+
+// Contains reports whether a VLAN is in the range.
+func (r Range) Contains(vlan int) bool {
+	return vlan >= r.From && vlan <= r.To
+}
+
+// Domain is a domain attached to an AEP and its VLAN pool.
+type Domain struct {
+	DN    string
+	Pool  string
+	VLANs []Range
+}
+
+// Chain is the access policy chain of a leaf interface.
+// Fields are empty where the chain is broken, e.g. a policy group without an AEP.
+type Chain struct {
+	Node string
+	// Port is the interface, e.g. eth1/1.
+	Port             string
+	SwitchProfile    string
+	InterfaceProfile string
+	Selector         string
+	PolicyGroup      string
+	// Bundle is link for port channels, node for vPCs, or empty for access ports.
+	Bundle  string
+	AEP     string
+	Domains []Domain
+}
+
+// Deploys reports whether a VLAN is in the pool of any of the chain's domains.
+func (c Chain) Deploys(vlan int) bool {
+	for _, d := range c.Domains {
+		for _, r := range d.VLANs {
+			if r.Contains(vlan) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resolver resolves access policy chains.
+type Resolver struct {
+	chains []Chain
+}
+
+// New builds the access policy chains of every selected leaf interface from
+// infraNodeP, infraAccPortP, infraHPortS, policy group, AEP, domain and VLAN
+// pool MOs.
+func New(db *mit.DB) (*Resolver, error) {
+	var l loader
+	if err := l.load(db); err != nil {
+		return nil, err
+	}
+	r := &Resolver{}
+	for profile, portProfiles := range l.portProfiles {
+		for _, node := range l.nodes[profile] {
+			for _, portProfile := range portProfiles {
+				for _, sel := range l.selectors[portProfile] {
+					for _, port := range l.ports[sel] {
+						r.chains = append(r.chains, l.chain(node, port, profile, portProfile, sel))
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(r.chains, func(i, j int) bool {
+		a, b := r.chains[i], r.chains[j]
+		if a.Node != b.Node {
+			return mit.LessNumeric(a.Node, b.Node)
+		}
+		if a.Port != b.Port {
+			return mit.LessNumeric(a.Port, b.Port)
+		}
+		return a.Selector < b.Selector
+	})
+	return r, nil
+}
+
+// Chains returns every resolved chain sorted by node and port.
+func (r *Resolver) Chains() []Chain {
+	return r.chains
+}
+
+// Port returns the chains of a leaf interface, e.g. Port("101", "eth1/1").
+// Several chains are returned if the interface is selected more than once.
+func (r *Resolver) Port(node, port string) ([]Chain, error) {
+	var res []Chain
+	for _, c := range r.chains {
+		if c.Node == node && c.Port == port {
+			res = append(res, c)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("ACCESS:PORT:%s/%s:%w", node, port, mit.ErrNotFound)
+	}
+	return res, nil
+}
+
+// VLAN returns the chains of every interface a VLAN can be deployed on.
+func (r *Resolver) VLAN(vlan int) (res []Chain) {
+	for _, c := range r.chains {
+		if c.Deploys(vlan) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// loader indexes the access policy MOs by parent DN.
+type loader struct {
+	// nodes maps switch profiles to node IDs
+	nodes map[string][]string
+	// portProfiles maps switch profiles to interface profiles
+	portProfiles map[string][]string
+	// selectors maps interface profiles to port selectors
+	selectors map[string][]string
+	// ports maps port selectors to interfaces
+	ports map[string][]string
+	// group maps port selectors to policy groups
+	group map[string]string
+	// bundle maps bundle policy groups to their lag type
+	bundle map[string]string
+	aep    map[string]string
+	// domains maps AEPs to domains
+	domains map[string][]string
+	pool    map[string]string
+	vlans   map[string][]Range
+}
+
+func (l *loader) load(db *mit.DB) error {
+	l.nodes = map[string][]string{}
+	l.portProfiles = map[string][]string{}
+	l.selectors = map[string][]string{}
+	l.ports = map[string][]string{}
+	l.group = map[string]string{}
+	l.bundle = map[string]string{}
+	l.aep = map[string]string{}
+	l.domains = map[string][]string{}
+	l.pool = map[string]string{}
+	l.vlans = map[string][]Range{}
+
+	steps := []struct {
+		class string
+		fn    func(dn string, v gjson.Result)
+	}{
+		{"infraNodeBlk", func(dn string, v gjson.Result) {
+			// nprof-x/leaves-y-typ-range/nodeblk-z
+			profile := mit.ParentDN(mit.ParentDN(dn))
+			from, _ := strconv.Atoi(v.Get("from_").Str)
+			to, _ := strconv.Atoi(v.Get("to_").Str)
+			for id := from; id <= to && id > 0; id++ {
+				l.nodes[profile] = appendUnique(l.nodes[profile], strconv.Itoa(id))
+			}
+		}},
+		{"infraRsAccPortP", func(dn string, v gjson.Result) {
+			profile := mit.ParentDN(dn)
+			l.portProfiles[profile] = append(l.portProfiles[profile], v.Get("tDn").Str)
+		}},
+		{"infraHPortS", func(dn string, v gjson.Result) {
+			profile := mit.ParentDN(dn)
+			l.selectors[profile] = append(l.selectors[profile], dn)
+		}},
+		{"infraPortBlk", func(dn string, v gjson.Result) {
+			sel := mit.ParentDN(dn)
+			fromCard, _ := strconv.Atoi(v.Get("fromCard").Str)
+			toCard, _ := strconv.Atoi(v.Get("toCard").Str)
+			fromPort, _ := strconv.Atoi(v.Get("fromPort").Str)
+			toPort, _ := strconv.Atoi(v.Get("toPort").Str)
+			for card := fromCard; card <= toCard; card++ {
+				for port := fromPort; port <= toPort; port++ {
+					l.ports[sel] = appendUnique(l.ports[sel], fmt.Sprintf("eth%d/%d", card, port))
+				}
+			}
+		}},
+		{"infraRsAccBaseGrp", func(dn string, v gjson.Result) {
+			l.group[mit.ParentDN(dn)] = v.Get("tDn").Str
+		}},
+		{"infraAccBndlGrp", func(dn string, v gjson.Result) {
+			l.bundle[dn] = v.Get("lagT").Str
+		}},
+		{"infraRsAttEntP", func(dn string, v gjson.Result) {
+			l.aep[mit.ParentDN(dn)] = v.Get("tDn").Str
+		}},
+		{"infraRsDomP", func(dn string, v gjson.Result) {
+			aep := mit.ParentDN(dn)
+			l.domains[aep] = append(l.domains[aep], v.Get("tDn").Str)
+		}},
+		{"infraRsVlanNs", func(dn string, v gjson.Result) {
+			l.pool[mit.ParentDN(dn)] = v.Get("tDn").Str
+		}},
+		{"fvnsEncapBlk", func(dn string, v gjson.Result) {
+			from, errFrom := mit.VLANID(v.Get("from").Str)
+			to, errTo := mit.VLANID(v.Get("to").Str)
+			if errFrom == nil && errTo == nil {
+				pool := mit.ParentDN(dn)
+				l.vlans[pool] = append(l.vlans[pool], Range{From: from, To: to})
+			}
+		}},
+	}
+	for _, step := range steps {
+		err := db.FindEach(step.class+":*", func(_ string, v gjson.Result) bool {
+			step.fn(v.Get("dn").Str, v)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, domains := range l.domains {
+		sort.Strings(domains)
+	}
+	return nil
+}
+
+// chain builds the chain of an interface selected by a port selector.
+func (l *loader) chain(node, port, profile, portProfile, sel string) Chain {
+	c := Chain{
+		Node:             node,
+		Port:             port,
+		SwitchProfile:    profile,
+		InterfaceProfile: portProfile,
+		Selector:         sel,
+		PolicyGroup:      l.group[sel],
+		Bundle:           l.bundle[l.group[sel]],
+	}
+	c.AEP = l.aep[c.PolicyGroup]
+	for _, dn := range l.domains[c.AEP] {
+		pool := l.pool[dn]
+		c.Domains = append(c.Domains, Domain{DN: dn, Pool: pool, VLANs: l.vlans[pool]})
+	}
+	return c
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
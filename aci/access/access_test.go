@@ -0,0 +1,72 @@
+package access
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestResolver(t *testing.T) *Resolver {
+	db := mittest.Folder(t, "testdata")
+	r, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPort(t *testing.T) {
+	a := assert.New(t)
+	r := newTestResolver(t)
+	// eth1/1-4 and eth1/10 on 101, and eth1/20 on 101 and 102
+	a.Len(r.Chains(), 7)
+
+	res, err := r.Port("101", "eth1/3")
+	a.NoError(err)
+	a.Equal([]Chain{{
+		Node:             "101",
+		Port:             "eth1/3",
+		SwitchProfile:    "uni/infra/nprof-Leaf101",
+		InterfaceProfile: "uni/infra/accportprof-Leaf101",
+		Selector:         "uni/infra/accportprof-Leaf101/hports-servers-typ-range",
+		PolicyGroup:      "uni/infra/funcprof/accportgrp-Servers",
+		AEP:              "uni/infra/attentp-servers",
+		Domains: []Domain{
+			{DN: "uni/phys-phys", Pool: "uni/infra/vlanns-[phys]-static", VLANs: []Range{{100, 199}}},
+			{DN: "uni/vmmp-VMware/dom-vds", Pool: "uni/infra/vlanns-[vmm]-dynamic", VLANs: []Range{{1000, 1999}}},
+		},
+	}}, res)
+
+	res, err = r.Port("102", "eth1/20")
+	a.NoError(err)
+	a.Len(res, 1)
+	a.Equal("node", res[0].Bundle)
+	a.Equal("uni/infra/funcprof/accbundle-ESX_vPC", res[0].PolicyGroup)
+
+	res, err = r.Port("101", "eth1/10")
+	a.NoError(err)
+	a.Equal("link", res[0].Bundle)
+	a.True(res[0].Deploys(305))
+	a.False(res[0].Deploys(150))
+
+	_, err = r.Port("102", "eth1/1")
+	a.ErrorIs(err, mit.ErrNotFound)
+}
+
+func TestVLAN(t *testing.T) {
+	a := assert.New(t)
+	r := newTestResolver(t)
+	var ports []string
+	for _, c := range r.VLAN(150) {
+		ports = append(ports, c.Node+":"+c.Port)
+	}
+	a.Equal([]string{
+		"101:eth1/1", "101:eth1/2", "101:eth1/3", "101:eth1/4",
+		"101:eth1/20", "102:eth1/20",
+	}, ports)
+	a.Len(r.VLAN(300), 1)
+	a.Empty(r.VLAN(4000))
+}
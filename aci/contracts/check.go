@@ -0,0 +1,261 @@
+package contracts
+
+import (
+	"fmt"
+	"sort"
+
+	"lib/aci/mit"
+)
+
+// Reasons traffic is allowed
+const (
+	ReasonContract       = "contract"
+	ReasonIntraEPG       = "intra-epg"
+	ReasonPreferredGroup = "preferred-group"
+	ReasonUnenforced     = "unenforced"
+)
+
+// Flow is traffic from a consumer to a provider port.
+type Flow struct {
+	// Protocol is e.g. tcp, udp or icmp.
+	Protocol string
+	// Port is the destination port, or 0 for any port.
+	Port int
+}
+
+// TCP returns a TCP flow to a port.
+func TCP(port int) Flow {
+	return Flow{Protocol: "tcp", Port: port}
+}
+
+// UDP returns a UDP flow to a port.
+func UDP(port int) Flow {
+	return Flow{Protocol: "udp", Port: port}
+}
+
+func (f Flow) String() string {
+	return fmt.Sprintf("%s/%d", f.Protocol, f.Port)
+}
+
+// Match is a filter entry matching a flow.
+type Match struct {
+	Contract string
+	Subject  string
+	Filter   string
+	Entry    string
+	Action   string
+	// VzAny reports whether the contract applies through vzAny.
+	VzAny bool
+}
+
+// Result is the outcome of a reachability query.
+type Result struct {
+	Allowed bool
+	// Reason is why the flow is allowed, e.g. ReasonContract or ReasonPreferredGroup.
+	Reason string
+	// Matches are the filter entries matching the flow, including denies.
+	Matches []Match
+}
+
+// Check reports whether src can reach dst with a flow, i.e. src consumes a
+// contract provided by dst with a permit entry matching the flow and no deny
+// entry. Flows are also allowed within an EPG without intra-EPG isolation,
+// between preferred group members, and in unenforced VRFs.
+//
+//	res, err := g.Check("uni/tn-a/ap-b/epg-web", "uni/tn-a/ap-b/epg-app", contracts.TCP(443))
+func (g *Graph) Check(src, dst string, flow Flow) (Result, error) {
+	from, ok := g.EPGs[src]
+	if !ok {
+		return Result{}, fmt.Errorf("CONTRACTS:EPG:%s:%w", src, mit.ErrNotFound)
+	}
+	to, ok := g.EPGs[dst]
+	if !ok {
+		return Result{}, fmt.Errorf("CONTRACTS:EPG:%s:%w", dst, mit.ErrNotFound)
+	}
+	vrf := VRF{Enforced: true}
+	if v, ok := g.VRFs[from.VRF]; ok {
+		vrf = *v
+	}
+	switch {
+	case src == dst && from.Isolated:
+		// Intra-EPG contracts are not modeled yet; isolated EPGs deny
+		return Result{}, nil
+	case src == dst:
+		return Result{Allowed: true, Reason: ReasonIntraEPG}, nil
+	case from.VRF != to.VRF:
+		// Shared services are not modeled yet; require a contract
+	case !vrf.Enforced:
+		return Result{Allowed: true, Reason: ReasonUnenforced}, nil
+	case from.Preferred && to.Preferred && vrf.Preferred:
+		return Result{Allowed: true, Reason: ReasonPreferredGroup}, nil
+	}
+
+	res := Result{Matches: g.matches(from, to, flow)}
+	for _, m := range res.Matches {
+		if m.Action == "deny" {
+			return Result{Matches: res.Matches}, nil
+		}
+		res.Allowed = true
+		res.Reason = ReasonContract
+	}
+	return res, nil
+}
+
+// matches returns the entries of contracts between a consumer and provider
+// that match a flow.
+func (g *Graph) matches(from, to *EPG, flow Flow) (res []Match) {
+	for _, edge := range g.edges(from, to) {
+		c, ok := g.Contracts[edge.Contract]
+		if !ok {
+			continue
+		}
+		for _, s := range c.Subjects {
+			for _, ref := range s.Filters {
+				if ref.Direction == ProviderConsumer {
+					continue
+				}
+				f, ok := g.Filters[ref.Filter]
+				if !ok {
+					continue
+				}
+				for _, e := range f.Entries {
+					if e.Matches(flow) {
+						res = append(res, Match{
+							Contract: c.DN,
+							Subject:  s.DN,
+							Filter:   f.DN,
+							Entry:    e.DN,
+							Action:   ref.Action,
+							VzAny:    edge.VzAny,
+						})
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Matches reports whether an entry matches a flow from an ephemeral source port.
+func (e Entry) Matches(flow Flow) bool {
+	switch e.EtherType {
+	case "", "unspecified":
+		// Any traffic
+	case "ip", "ipv4", "ipv6":
+		if flow.Protocol == "arp" {
+			return false
+		}
+	default:
+		return e.EtherType == flow.Protocol
+	}
+	if e.Protocol != "" && e.Protocol != "unspecified" && e.Protocol != flow.Protocol {
+		return false
+	}
+	if e.Protocol != "tcp" && e.Protocol != "udp" {
+		return true
+	}
+	if e.SFrom != 0 {
+		return false
+	}
+	if e.DFrom == 0 {
+		return true
+	}
+	to := e.DTo
+	if to == 0 {
+		to = e.DFrom
+	}
+	return flow.Port >= e.DFrom && flow.Port <= to
+}
+
+// Edge is a contract from a consumer to a provider.
+type Edge struct {
+	Consumer string
+	Provider string
+	Contract string
+	// VzAny reports whether either end consumes or provides through vzAny.
+	VzAny bool
+}
+
+// Edges returns the contract edges between EPGs, with vzAny contracts
+// expanded to the EPGs in the VRF.
+func (g *Graph) Edges() (res []Edge) {
+	for _, from := range g.EPGs {
+		for _, to := range g.EPGs {
+			if from != to {
+				res = append(res, g.edges(from, to)...)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Consumer != b.Consumer {
+			return a.Consumer < b.Consumer
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Contract < b.Contract
+	})
+	return res
+}
+
+// edges returns the contracts consumed by one EPG and provided by another
+// within the contract scope.
+func (g *Graph) edges(from, to *EPG) (res []Edge) {
+	consumes := map[string]bool{}
+	for _, c := range from.Consumes {
+		consumes[c] = false
+	}
+	if vrf, ok := g.VRFs[from.VRF]; ok {
+		for _, c := range vrf.Consumes {
+			if _, ok := consumes[c]; !ok {
+				consumes[c] = true
+			}
+		}
+	}
+	provides := map[string]bool{}
+	for _, c := range to.Provides {
+		provides[c] = false
+	}
+	if vrf, ok := g.VRFs[to.VRF]; ok {
+		for _, c := range vrf.Provides {
+			if _, ok := provides[c]; !ok {
+				provides[c] = true
+			}
+		}
+	}
+	for c, anyCons := range consumes {
+		anyProv, ok := provides[c]
+		if !ok || !g.inScope(c, from, to) {
+			continue
+		}
+		res = append(res, Edge{
+			Consumer: from.DN,
+			Provider: to.DN,
+			Contract: c,
+			VzAny:    anyCons || anyProv,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Contract < res[j].Contract })
+	return res
+}
+
+// inScope reports whether a contract applies between two EPGs.
+func (g *Graph) inScope(contract string, from, to *EPG) bool {
+	scope := "context"
+	if c, ok := g.Contracts[contract]; ok && c.Scope != "" {
+		scope = c.Scope
+	}
+	switch scope {
+	case "global":
+		return true
+	case "tenant":
+		a, _ := mit.TenantDN(from.DN)
+		b, _ := mit.TenantDN(to.DN)
+		return a == b
+	case "application-profile":
+		return mit.ParentDN(from.DN) == mit.ParentDN(to.DN)
+	default:
+		return from.VRF == to.VRF
+	}
+}
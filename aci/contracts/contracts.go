@@ -0,0 +1,341 @@
+// Package contracts builds the contract graph between EPGs, ESGs and L3Out
+// external EPGs and answers reachability queries, e.g. whether one EPG can
+// reach another on TCP/443 and through which contract and filter entry.
+package contracts
+
+import (
+	"sort"
+	"strings"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// EPG is an endpoint group, i.e. an fvAEPg, fvESg or l3extInstP.
+type EPG struct {
+	DN    string
+	Class string
+	// VRF is the DN of the EPG's VRF.
+	VRF string
+	// Preferred reports whether the EPG is a preferred group member.
+	Preferred bool
+	// Isolated reports whether intra-EPG isolation is enforced, i.e.
+	// pcEnfPref is enforced.
+	Isolated bool
+	// Provides and Consumes are contract DNs.
+	Provides []string
+	Consumes []string
+}
+
+// VRF is a VRF and its vzAny contracts.
+type VRF struct {
+	DN       string
+	Enforced bool
+	// Preferred reports whether the preferred group is enabled.
+	Preferred bool
+	// Provides and Consumes are the vzAny contract DNs.
+	Provides []string
+	Consumes []string
+}
+
+// Contract is a vzBrCP.
+type Contract struct {
+	DN       string
+	Name     string
+	Scope    string
+	Subjects []*Subject
+}
+
+// Subject is a contract subject.
+type Subject struct {
+	DN   string
+	Name string
+	// Reverse reports whether filter ports are reversed for return traffic.
+	Reverse bool
+	Filters []FilterRef
+}
+
+// Filter directions
+const (
+	Both             = "both"
+	ConsumerProvider = "consumer-to-provider"
+	ProviderConsumer = "provider-to-consumer"
+)
+
+// FilterRef is a filter applied by a subject.
+type FilterRef struct {
+	Filter string
+	// Action is permit or deny.
+	Action    string
+	Direction string
+}
+
+// Filter is a vzFilter.
+type Filter struct {
+	DN      string
+	Entries []Entry
+}
+
+// Entry is a filter entry. Port ranges are 0 when unspecified.
+type Entry struct {
+	DN        string
+	Name      string
+	EtherType string
+	Protocol  string
+	DFrom     int
+	DTo       int
+	SFrom     int
+	STo       int
+}
+
+// Graph is the contract graph of a fabric.
+type Graph struct {
+	EPGs      map[string]*EPG
+	VRFs      map[string]*VRF
+	Contracts map[string]*Contract
+	Filters   map[string]*Filter
+}
+
+// New builds the contract graph from the policy MOs in a DB.
+// Missing classes are skipped.
+func New(db *mit.DB) (*Graph, error) {
+	g := &Graph{
+		EPGs:      map[string]*EPG{},
+		VRFs:      map[string]*VRF{},
+		Contracts: map[string]*Contract{},
+		Filters:   map[string]*Filter{},
+	}
+	vrfs, err := mit.VRFs(db)
+	if err != nil {
+		return nil, err
+	}
+	subjects := map[string]*Subject{}
+
+	steps := []struct {
+		class string
+		fn    func(dn string, v gjson.Result)
+	}{
+		{"fvCtx", func(dn string, v gjson.Result) {
+			g.vrf(dn).Enforced = v.Get("pcEnfPref").Str != "unenforced"
+		}},
+		{"vzAny", func(dn string, v gjson.Result) {
+			g.vrf(mit.ParentDN(dn)).Preferred = v.Get("prefGrMemb").Str == "enabled"
+		}},
+		{"vzRsAnyToProv", func(dn string, v gjson.Result) {
+			vrf := g.vrf(mit.ParentDN(mit.ParentDN(dn)))
+			vrf.Provides = append(vrf.Provides, target(v, dn, "brc-", "tnVzBrCPName"))
+		}},
+		{"vzRsAnyToCons", func(dn string, v gjson.Result) {
+			vrf := g.vrf(mit.ParentDN(mit.ParentDN(dn)))
+			vrf.Consumes = append(vrf.Consumes, target(v, dn, "brc-", "tnVzBrCPName"))
+		}},
+		{"fvAEPg", g.addEPG("fvAEPg")},
+		{"fvESg", g.addEPG("fvESg")},
+		{"l3extInstP", g.addEPG("l3extInstP")},
+		{"fvRsScope", func(dn string, v gjson.Result) {
+			g.epg(mit.ParentDN(dn)).VRF = target(v, dn, "ctx-", "tnFvCtxName")
+		}},
+		{"l3extRsEctx", func(dn string, v gjson.Result) {
+			// Applies to every external EPG in the L3Out
+			out := mit.ParentDN(dn)
+			vrf := target(v, dn, "ctx-", "tnFvCtxName")
+			for epgDN, epg := range g.EPGs {
+				if mit.ParentDN(epgDN) == out {
+					epg.VRF = vrf
+				}
+			}
+		}},
+		{"fvRsProv", func(dn string, v gjson.Result) {
+			epg := g.epg(mit.ParentDN(dn))
+			epg.Provides = append(epg.Provides, target(v, dn, "brc-", "tnVzBrCPName"))
+		}},
+		{"fvRsCons", func(dn string, v gjson.Result) {
+			epg := g.epg(mit.ParentDN(dn))
+			epg.Consumes = append(epg.Consumes, target(v, dn, "brc-", "tnVzBrCPName"))
+		}},
+		{"vzBrCP", func(dn string, v gjson.Result) {
+			c := g.contract(dn)
+			c.Name = v.Get("name").Str
+			c.Scope = v.Get("scope").Str
+		}},
+		{"vzSubj", func(dn string, v gjson.Result) {
+			s := &Subject{
+				DN:      dn,
+				Name:    v.Get("name").Str,
+				Reverse: v.Get("revFltPorts").Str != "no",
+			}
+			subjects[dn] = s
+			c := g.contract(mit.ParentDN(dn))
+			c.Subjects = append(c.Subjects, s)
+		}},
+		{"vzRsSubjFiltAtt", func(dn string, v gjson.Result) {
+			if s, ok := subjects[mit.ParentDN(dn)]; ok {
+				s.Filters = append(s.Filters, filterRef(v, dn, Both))
+			}
+		}},
+		{"vzRsFiltAtt", func(dn string, v gjson.Result) {
+			term := mit.ParentDN(dn)
+			s, ok := subjects[mit.ParentDN(term)]
+			if !ok {
+				return
+			}
+			dir := ConsumerProvider
+			if mit.LastRN(term) == "outtmnl" {
+				dir = ProviderConsumer
+			}
+			s.Filters = append(s.Filters, filterRef(v, dn, dir))
+		}},
+		{"vzFilter", func(dn string, v gjson.Result) {
+			g.filter(dn)
+		}},
+		{"vzEntry", func(dn string, v gjson.Result) {
+			f := g.filter(mit.ParentDN(dn))
+			f.Entries = append(f.Entries, newEntry(dn, v))
+		}},
+	}
+	for _, step := range steps {
+		err := db.FindEach(step.class+":*", func(_ string, v gjson.Result) bool {
+			step.fn(v.Get("dn").Str, v)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for dn, epg := range g.EPGs {
+		if epg.VRF == "" {
+			epg.VRF = vrfs[dn]
+		}
+		sort.Strings(epg.Provides)
+		sort.Strings(epg.Consumes)
+	}
+	g.resolveCommon()
+	return g, nil
+}
+
+func (g *Graph) addEPG(class string) func(string, gjson.Result) {
+	return func(dn string, v gjson.Result) {
+		epg := g.epg(dn)
+		epg.Class = class
+		epg.Preferred = v.Get("prefGrMemb").Str == "include"
+		epg.Isolated = v.Get("pcEnfPref").Str == "enforced"
+	}
+}
+
+func (g *Graph) epg(dn string) *EPG {
+	epg, ok := g.EPGs[dn]
+	if !ok {
+		epg = &EPG{DN: dn}
+		g.EPGs[dn] = epg
+	}
+	return epg
+}
+
+func (g *Graph) vrf(dn string) *VRF {
+	vrf, ok := g.VRFs[dn]
+	if !ok {
+		vrf = &VRF{DN: dn, Enforced: true}
+		g.VRFs[dn] = vrf
+	}
+	return vrf
+}
+
+func (g *Graph) contract(dn string) *Contract {
+	c, ok := g.Contracts[dn]
+	if !ok {
+		c = &Contract{DN: dn}
+		g.Contracts[dn] = c
+	}
+	return c
+}
+
+func (g *Graph) filter(dn string) *Filter {
+	f, ok := g.Filters[dn]
+	if !ok {
+		f = &Filter{DN: dn}
+		g.Filters[dn] = f
+	}
+	return f
+}
+
+// resolveCommon points relations by name to the common tenant when the
+// target does not exist in the local tenant, as the APIC does.
+func (g *Graph) resolveCommon() {
+	contract := func(dns []string) {
+		for i, dn := range dns {
+			if _, ok := g.Contracts[dn]; !ok {
+				if common := commonDN(dn); g.Contracts[common] != nil {
+					dns[i] = common
+				}
+			}
+		}
+	}
+	for _, epg := range g.EPGs {
+		contract(epg.Provides)
+		contract(epg.Consumes)
+		if _, ok := g.VRFs[epg.VRF]; !ok && g.VRFs[commonDN(epg.VRF)] != nil {
+			epg.VRF = commonDN(epg.VRF)
+		}
+	}
+	for _, vrf := range g.VRFs {
+		contract(vrf.Provides)
+		contract(vrf.Consumes)
+	}
+	for _, c := range g.Contracts {
+		for _, s := range c.Subjects {
+			for i, ref := range s.Filters {
+				if _, ok := g.Filters[ref.Filter]; !ok && g.Filters[commonDN(ref.Filter)] != nil {
+					s.Filters[i].Filter = commonDN(ref.Filter)
+				}
+			}
+		}
+	}
+}
+
+// target returns the target DN of a relation, falling back to the named
+// target in the relation's tenant.
+func target(v gjson.Result, dn, prefix, name string) string {
+	if tDn := v.Get("tDn").Str; tDn != "" {
+		return tDn
+	}
+	tenant, _ := mit.TenantDN(dn)
+	return "uni/tn-" + tenant + "/" + prefix + v.Get(name).Str
+}
+
+func filterRef(v gjson.Result, dn, dir string) FilterRef {
+	action := v.Get("action").Str
+	if action == "" {
+		action = "permit"
+	}
+	return FilterRef{
+		Filter:    target(v, dn, "flt-", "tnVzFilterName"),
+		Action:    action,
+		Direction: dir,
+	}
+}
+
+func newEntry(dn string, v gjson.Result) Entry {
+	return Entry{
+		DN:        dn,
+		Name:      v.Get("name").Str,
+		EtherType: v.Get("etherT").Str,
+		Protocol:  v.Get("prot").Str,
+		DFrom:     port(v.Get("dFromPort").Str),
+		DTo:       port(v.Get("dToPort").Str),
+		SFrom:     port(v.Get("sFromPort").Str),
+		STo:       port(v.Get("sToPort").Str),
+	}
+}
+
+// commonDN returns the DN of an object in the common tenant,
+// e.g. uni/tn-common/brc-default for uni/tn-a/brc-default.
+func commonDN(dn string) string {
+	rns := mit.SplitDN(dn)
+	if len(rns) < 3 {
+		return dn
+	}
+	rns[1] = "tn-common"
+	return strings.Join(rns, "/")
+}
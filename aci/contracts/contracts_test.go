@@ -0,0 +1,155 @@
+package contracts
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	web  = "uni/tn-Ent/ap-shop/epg-web"
+	app  = "uni/tn-Ent/ap-shop/epg-app"
+	db   = "uni/tn-Ent/ap-shop/epg-db"
+	dns  = "uni/tn-Ent/ap-shop/epg-dns"
+	pci  = "uni/tn-Ent/ap-shop/esg-pci"
+	inet = "uni/tn-Ent/out-inet/instP-any"
+	lab1 = "uni/tn-Ent/ap-lab/epg-lab1"
+	lab2 = "uni/tn-Ent/ap-lab/epg-lab2"
+)
+
+func newTestGraph(t *testing.T) *Graph {
+	mdb := mittest.Folder(t, "testdata")
+	g, err := New(mdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+	g := newTestGraph(t)
+	a.Len(g.EPGs, 9)
+	a.Equal("uni/tn-Ent/ctx-prod", g.EPGs[web].VRF)
+	a.Equal("uni/tn-Ent/ctx-prod", g.EPGs[pci].VRF)
+	a.Equal("uni/tn-Ent/ctx-prod", g.EPGs[inet].VRF)
+	a.Equal("l3extInstP", g.EPGs[inet].Class)
+	a.True(g.EPGs[db].Preferred)
+	a.True(g.EPGs[app].Isolated)
+	a.False(g.EPGs[web].Isolated)
+	a.Equal([]string{
+		"uni/tn-Ent/brc-app",
+		"uni/tn-Ent/brc-ssh",
+		"uni/tn-common/brc-default",
+	}, g.EPGs[web].Consumes)
+	a.Equal([]string{"uni/tn-Ent/brc-dns"}, g.VRFs["uni/tn-Ent/ctx-prod"].Consumes)
+	a.False(g.VRFs["uni/tn-Ent/ctx-lab"].Enforced)
+
+	ssh := g.Contracts["uni/tn-Ent/brc-ssh"].Subjects[0]
+	a.Len(ssh.Filters, 2)
+	a.Equal(FilterRef{Filter: "uni/tn-Ent/flt-ssh", Action: "permit", Direction: ConsumerProvider}, ssh.Filters[0])
+
+	https := g.Filters["uni/tn-Ent/flt-https"].Entries[0]
+	a.Equal(443, https.DFrom)
+	a.Equal(443, https.DTo)
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+	g := newTestGraph(t)
+
+	res, err := g.Check(inet, web, TCP(443))
+	a.NoError(err)
+	a.True(res.Allowed)
+	a.Equal(ReasonContract, res.Reason)
+	a.Equal([]Match{{
+		Contract: "uni/tn-Ent/brc-web",
+		Subject:  "uni/tn-Ent/brc-web/subj-https",
+		Filter:   "uni/tn-Ent/flt-https",
+		Entry:    "uni/tn-Ent/flt-https/e-https",
+		Action:   "permit",
+	}}, res.Matches)
+
+	for _, tc := range []struct {
+		src, dst string
+		flow     Flow
+		allowed  bool
+		reason   string
+	}{
+		{inet, web, TCP(80), false, ""},
+		{web, inet, TCP(443), false, ""},
+		{web, app, TCP(8080), true, ReasonContract},
+		{web, app, TCP(8090), true, ReasonContract},
+		// Deny entries win
+		{web, app, TCP(8081), false, ""},
+		// Directional filter
+		{web, app, TCP(22), true, ReasonContract},
+		{app, web, TCP(22), false, ""},
+		{app, web, TCP(8080), false, ""},
+		// vzAny consumes DNS
+		{web, dns, UDP(53), true, ReasonContract},
+		{app, dns, UDP(53), true, ReasonContract},
+		{app, dns, TCP(53), false, ""},
+		{web, web, TCP(1), true, ReasonIntraEPG},
+		{pci, pci, TCP(1), true, ReasonIntraEPG},
+		// Intra-EPG isolation
+		{app, app, TCP(1), false, ""},
+		{db, "uni/tn-Ent/ap-shop/epg-cache", TCP(1), true, ReasonPreferredGroup},
+		{web, db, TCP(1), false, ""},
+		{lab1, lab2, TCP(1), true, ReasonUnenforced},
+		{lab1, web, TCP(443), false, ""},
+		// Common tenant filter, application profile scope
+		{app, pci, TCP(1234), true, ReasonContract},
+	} {
+		res, err := g.Check(tc.src, tc.dst, tc.flow)
+		a.NoError(err)
+		a.Equal(tc.allowed, res.Allowed, "%s -> %s %s", tc.src, tc.dst, tc.flow)
+		a.Equal(tc.reason, res.Reason, "%s -> %s %s", tc.src, tc.dst, tc.flow)
+	}
+
+	res, err = g.Check(web, app, TCP(8081))
+	a.NoError(err)
+	a.Len(res.Matches, 2)
+
+	res, err = g.Check(web, dns, UDP(53))
+	a.NoError(err)
+	a.True(res.Matches[0].VzAny)
+
+	_, err = g.Check(web, "uni/tn-Ent/ap-shop/epg-missing", TCP(1))
+	a.ErrorIs(err, mit.ErrNotFound)
+}
+
+func TestEdges(t *testing.T) {
+	a := assert.New(t)
+	g := newTestGraph(t)
+	edges := g.Edges()
+	var fromWeb []string
+	for _, e := range edges {
+		if e.Consumer == web {
+			fromWeb = append(fromWeb, e.Provider+" "+e.Contract)
+		}
+	}
+	a.Equal([]string{
+		"uni/tn-Ent/ap-shop/epg-app uni/tn-Ent/brc-app",
+		"uni/tn-Ent/ap-shop/epg-app uni/tn-Ent/brc-ssh",
+		"uni/tn-Ent/ap-shop/epg-dns uni/tn-Ent/brc-dns",
+	}, fromWeb)
+}
+
+func TestEntryMatches(t *testing.T) {
+	a := assert.New(t)
+	all := Entry{EtherType: "unspecified", Protocol: "unspecified"}
+	a.True(all.Matches(TCP(1)))
+	a.True(all.Matches(Flow{Protocol: "icmp"}))
+	icmp := Entry{EtherType: "ip", Protocol: "icmp"}
+	a.True(icmp.Matches(Flow{Protocol: "icmp"}))
+	a.False(icmp.Matches(TCP(1)))
+	// Return traffic entries do not match new flows
+	ret := Entry{EtherType: "ip", Protocol: "tcp", SFrom: 443, STo: 443}
+	a.False(ret.Matches(TCP(443)))
+	a.Equal(53, port("dns"))
+	a.Equal(0, port("unspecified"))
+}
@@ -0,0 +1,27 @@
+package contracts
+
+import "strconv"
+
+// namedPorts are the port names used by filter entries.
+var namedPorts = map[string]int{
+	"ftpData": 20,
+	"ssh":     22,
+	"smtp":    25,
+	"dns":     53,
+	"http":    80,
+	"pop3":    110,
+	"https":   443,
+	"rtsp":    554,
+}
+
+// port parses a filter entry port, returning 0 for unspecified ports.
+func port(s string) int {
+	if n, ok := namedPorts[s]; ok {
+		return n
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
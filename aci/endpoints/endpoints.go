@@ -0,0 +1,366 @@
+// Package endpoints finds endpoints by MAC, IP or encap with their location,
+// EPG, BD and VRF.
+package endpoints
+
+import (
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// pathDN matches a path endpoint, e.g. topology/pod-1/paths-101/pathep-[eth1/1]
+// or topology/pod-1/protpaths-101-102/pathep-[vpc]
+var pathDN = regexp.MustCompile(`^topology/pod-\d+/(?:prot)?paths-([\d-]+)/(?:ext(?:prot)?paths-[\d-]+/)?pathep-\[(.+)\]$`)
+
+// epmDN matches the VRF, BD and VLAN segments of an epm record DN.
+var epmDN = regexp.MustCompile(`/ctx-\[vxlan-(\d+)\](?:/bd-\[vxlan-(\d+)\])?(?:/vlan-\[([^\]]+)\])?/`)
+
+// Location is where an endpoint is learned.
+type Location struct {
+	// Node is the leaf ID, or both IDs of a vPC pair, e.g. 101-102.
+	Node string
+	// Interface is the port, port channel or vPC, e.g. eth1/1.
+	Interface string
+	// Path is the path endpoint DN, if known.
+	Path string
+	// Source is the class the location was learned from.
+	Source string
+}
+
+// Endpoint is a MAC address and its IPs in an EPG.
+type Endpoint struct {
+	// DN is the fvCEp DN, or empty for endpoints only in epm records.
+	DN string
+	// MAC is empty for IPs learned by leaves without an fvCEp or fvIp.
+	MAC       string
+	IPs       []string
+	Encap     string
+	EPG       string
+	BD        string
+	VRF       string
+	Locations []Location
+}
+
+// Index is a searchable set of endpoints.
+type Index struct {
+	Endpoints []*Endpoint
+}
+
+// New builds the endpoint index from fvCEp, fvIp, fvRsCEpToPathEp, epmMacEp
+// and epmIpEp MOs. BD and VRF are resolved from fvRsBd and fvRsCtx, and epm
+// records are mapped to VRFs and BDs by VNID.
+func New(db *mit.DB) (*Index, error) {
+	var l loader
+	if err := l.load(db); err != nil {
+		return nil, err
+	}
+	x := &Index{}
+	for _, ep := range l.endpoints {
+		sort.Strings(ep.IPs)
+		x.Endpoints = append(x.Endpoints, ep)
+	}
+	sort.Slice(x.Endpoints, func(i, j int) bool {
+		a, b := x.Endpoints[i], x.Endpoints[j]
+		if a.MAC != b.MAC {
+			return a.MAC < b.MAC
+		}
+		return a.DN < b.DN
+	})
+	return x, nil
+}
+
+// ByMAC returns the endpoints with a MAC address in any common format,
+// e.g. 00:50:56:aa:bb:cc or 0050.56aa.bbcc.
+func (x *Index) ByMAC(mac string) []*Endpoint {
+	mac = NormalizeMAC(mac)
+	return x.filter(func(ep *Endpoint) bool { return ep.MAC == mac })
+}
+
+// ByIP returns the endpoints with an IP address.
+func (x *Index) ByIP(ip string) []*Endpoint {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	return x.filter(func(ep *Endpoint) bool {
+		for _, s := range ep.IPs {
+			if a, err := netip.ParseAddr(s); err == nil && a == addr {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ByEncap returns the endpoints with an encap, e.g. vlan-100 or 100.
+func (x *Index) ByEncap(encap string) []*Endpoint {
+	if !strings.Contains(encap, "-") {
+		encap = "vlan-" + encap
+	}
+	return x.filter(func(ep *Endpoint) bool { return ep.Encap == encap })
+}
+
+func (x *Index) filter(fn func(*Endpoint) bool) (res []*Endpoint) {
+	for _, ep := range x.Endpoints {
+		if fn(ep) {
+			res = append(res, ep)
+		}
+	}
+	return res
+}
+
+// Duplicate is an IP learned on more than one MAC in a VRF.
+type Duplicate struct {
+	IP        string
+	VRF       string
+	MACs      []string
+	Endpoints []*Endpoint
+}
+
+// DuplicateIPs returns the IPs learned on more than one MAC in the same VRF.
+func (x *Index) DuplicateIPs() (res []Duplicate) {
+	byIP := map[string]*Duplicate{}
+	var keys []string
+	for _, ep := range x.Endpoints {
+		for _, ip := range ep.IPs {
+			key := ep.VRF + "|" + ip
+			d, ok := byIP[key]
+			if !ok {
+				d = &Duplicate{IP: ip, VRF: ep.VRF}
+				byIP[key] = d
+				keys = append(keys, key)
+			}
+			d.Endpoints = append(d.Endpoints, ep)
+			if ep.MAC != "" && !contains(d.MACs, ep.MAC) {
+				d.MACs = append(d.MACs, ep.MAC)
+			}
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if d := byIP[key]; len(d.MACs) > 1 {
+			sort.Strings(d.MACs)
+			res = append(res, *d)
+		}
+	}
+	return res
+}
+
+// NormalizeMAC formats a MAC address as upper case colon separated hex,
+// the format used by the APIC.
+func NormalizeMAC(mac string) string {
+	hex := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'F':
+			return r
+		case r >= 'a' && r <= 'f':
+			return r - 'a' + 'A'
+		}
+		return -1
+	}, mac)
+	if len(hex) != 12 {
+		return strings.ToUpper(mac)
+	}
+	var b strings.Builder
+	for i := 0; i < 12; i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(hex[i : i+2])
+	}
+	return b.String()
+}
+
+// loader joins endpoint MOs.
+type loader struct {
+	endpoints map[string]*Endpoint
+	// byMAC and byIP index the endpoints for epm records
+	byMAC map[string][]*Endpoint
+	byIP  map[string][]*Endpoint
+	bd    map[string]string
+	// vrf maps BDs and EPGs to their VRF
+	vrf map[string]string
+	// vnid maps VRF and BD VNIDs to their DNs
+	vnid map[string]string
+}
+
+func (l *loader) load(db *mit.DB) error {
+	l.endpoints = map[string]*Endpoint{}
+	l.byMAC = map[string][]*Endpoint{}
+	l.byIP = map[string][]*Endpoint{}
+	l.bd = map[string]string{}
+	var err error
+	if l.vrf, err = mit.VRFs(db); err != nil {
+		return err
+	}
+	l.vnid = map[string]string{}
+
+	steps := []struct {
+		class string
+		fn    func(dn string, v gjson.Result)
+	}{
+		{"fvCtx", func(dn string, v gjson.Result) {
+			l.vnid[v.Get("scope").Str] = dn
+		}},
+		{"fvBD", func(dn string, v gjson.Result) {
+			l.vnid[v.Get("seg").Str] = dn
+		}},
+		{"fvRsBd", func(dn string, v gjson.Result) {
+			l.bd[mit.ParentDN(dn)] = v.Get("tDn").Str
+		}},
+		{"fvCEp", func(dn string, v gjson.Result) {
+			epg := mit.ParentDN(dn)
+			ep := &Endpoint{
+				DN:    dn,
+				MAC:   NormalizeMAC(v.Get("mac").Str),
+				Encap: v.Get("encap").Str,
+				EPG:   epg,
+				BD:    l.bd[epg],
+				VRF:   l.vrf[epg],
+			}
+			l.add(dn, ep)
+			if ip := v.Get("ip").Str; ip != "" && ip != "0.0.0.0" {
+				l.addIP(ep, ip)
+			}
+		}},
+		{"fvIp", func(dn string, v gjson.Result) {
+			if ep, ok := l.endpoints[mit.ParentDN(dn)]; ok {
+				l.addIP(ep, v.Get("addr").Str)
+			}
+		}},
+		{"fvRsCEpToPathEp", func(dn string, v gjson.Result) {
+			if ep, ok := l.endpoints[mit.ParentDN(dn)]; ok {
+				loc := Location{Path: v.Get("tDn").Str, Source: "fvRsCEpToPathEp"}
+				if m := pathDN.FindStringSubmatch(loc.Path); m != nil {
+					loc.Node, loc.Interface = m[1], m[2]
+				}
+				ep.Locations = append(ep.Locations, loc)
+			}
+		}},
+		{"epmMacEp", l.addMacEp},
+		{"epmIpEp", l.addIpEp},
+	}
+	for _, step := range steps {
+		err := db.FindEach(step.class+":*", func(_ string, v gjson.Result) bool {
+			step.fn(v.Get("dn").Str, v)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds an endpoint to the loader and the MAC index.
+func (l *loader) add(key string, ep *Endpoint) {
+	l.endpoints[key] = ep
+	if ep.MAC != "" {
+		l.byMAC[ep.MAC] = append(l.byMAC[ep.MAC], ep)
+	}
+}
+
+// addIP adds an IP to an endpoint and the IP index.
+func (l *loader) addIP(ep *Endpoint, ip string) {
+	if contains(ep.IPs, ip) {
+		return
+	}
+	ep.IPs = append(ep.IPs, ip)
+	key := ipKey(ip)
+	l.byIP[key] = append(l.byIP[key], ep)
+}
+
+// ipKey normalizes an IP for the index, e.g. IPv6 addresses in different
+// formats.
+func ipKey(ip string) string {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.String()
+	}
+	return ip
+}
+
+// addMacEp adds a leaf's MAC endpoint record to the endpoint with the MAC and
+// encap, or creates the endpoint if it is not in an EPG.
+func (l *loader) addMacEp(dn string, v gjson.Result) {
+	_, node, _ := mit.NodeDN(dn)
+	loc := Location{Node: node, Interface: v.Get("ifId").Str, Source: "epmMacEp"}
+	mac := NormalizeMAC(v.Get("addr").Str)
+	var vrf, bd, encap string
+	if m := epmDN.FindStringSubmatch(dn); m != nil {
+		vrf, bd, encap = l.vnid[m[1]], l.vnid[m[2]], m[3]
+	}
+	found := false
+	for _, ep := range l.byMAC[mac] {
+		if encap == "" || ep.Encap == encap {
+			ep.Locations = append(ep.Locations, loc)
+			found = true
+		}
+	}
+	if !found {
+		key := "epm|" + mac + "|" + encap
+		ep, ok := l.endpoints[key]
+		if !ok {
+			ep = &Endpoint{MAC: mac, Encap: encap, BD: bd, VRF: vrf}
+			l.add(key, ep)
+		}
+		ep.Locations = append(ep.Locations, loc)
+	}
+}
+
+// addIpEp adds a leaf's IP endpoint record to the endpoint with the IP in
+// the record's VRF. If several endpoints have the IP, it is added to those
+// already learned on the same node. IPs on no endpoint are added as
+// endpoints without a MAC.
+func (l *loader) addIpEp(dn string, v gjson.Result) {
+	_, node, _ := mit.NodeDN(dn)
+	loc := Location{Node: node, Interface: v.Get("ifId").Str, Source: "epmIpEp"}
+	var vrf string
+	if m := epmDN.FindStringSubmatch(dn); m != nil {
+		vrf = l.vnid[m[1]]
+	}
+	ip := v.Get("addr").Str
+	var candidates []*Endpoint
+	for _, ep := range l.byIP[ipKey(ip)] {
+		if vrf == "" || ep.VRF == vrf {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		ep := &Endpoint{VRF: vrf, Locations: []Location{loc}}
+		l.add("epm|"+vrf+"|"+ipKey(ip), ep)
+		l.addIP(ep, ip)
+		return
+	}
+	for _, ep := range candidates {
+		if len(candidates) == 1 || ep.learnedOn(node) {
+			ep.Locations = append(ep.Locations, loc)
+		}
+	}
+}
+
+// learnedOn reports whether the endpoint has a location on a node,
+// including either node of a vPC pair.
+func (ep *Endpoint) learnedOn(node string) bool {
+	for _, loc := range ep.Locations {
+		for _, id := range strings.Split(loc.Node, "-") {
+			if id == node {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
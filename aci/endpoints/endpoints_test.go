@@ -0,0 +1,105 @@
+package endpoints
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	db := mittest.Folder(t, "testdata")
+	x, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func TestByMAC(t *testing.T) {
+	a := assert.New(t)
+	x := newTestIndex(t)
+	a.Len(x.Endpoints, 6)
+
+	res := x.ByMAC("0050.56aa.0001")
+	if !a.Len(res, 1) {
+		return
+	}
+	ep := res[0]
+	a.Equal("uni/tn-Ent/ap-shop/epg-web/cep-00:50:56:AA:00:01", ep.DN)
+	a.Equal([]string{"10.1.1.10"}, ep.IPs)
+	a.Equal("vlan-100", ep.Encap)
+	a.Equal("uni/tn-Ent/ap-shop/epg-web", ep.EPG)
+	a.Equal("uni/tn-Ent/BD-web", ep.BD)
+	a.Equal("uni/tn-Ent/ctx-prod", ep.VRF)
+	a.Equal([]Location{
+		{Node: "101", Interface: "eth1/1", Path: "topology/pod-1/paths-101/pathep-[eth1/1]", Source: "fvRsCEpToPathEp"},
+		{Node: "101", Interface: "eth1/1", Source: "epmMacEp"},
+		{Node: "101", Interface: "eth1/1", Source: "epmIpEp"},
+	}, ep.Locations)
+
+	// vPC
+	ep = x.ByMAC("00-50-56-aa-00-02")[0]
+	a.Equal([]string{"10.1.1.20", "10.1.1.21"}, ep.IPs)
+	a.Equal("101-102", ep.Locations[0].Node)
+	a.Equal("ESX_vPC", ep.Locations[0].Interface)
+
+	// Only learned on a leaf
+	ep = x.ByMAC("00:50:56:bb:00:09")[0]
+	a.Empty(ep.DN)
+	a.Equal("vlan-200", ep.Encap)
+	a.Equal("uni/tn-Ent/BD-web", ep.BD)
+	a.Equal("uni/tn-Ent/ctx-prod", ep.VRF)
+	a.Equal([]Location{{Node: "102", Interface: "eth1/7", Source: "epmMacEp"}}, ep.Locations)
+
+	a.Empty(x.ByMAC("00:00:00:00:00:00"))
+}
+
+func TestByIP(t *testing.T) {
+	a := assert.New(t)
+	x := newTestIndex(t)
+	a.Len(x.ByIP("10.1.1.10"), 3)
+	a.Len(x.ByIP("10.1.1.21"), 1)
+	a.Empty(x.ByIP("10.9.9.9"))
+
+	// Only learned on leaves
+	res := x.ByIP("10.1.1.50")
+	if a.Len(res, 1) {
+		a.Empty(res[0].DN)
+		a.Empty(res[0].MAC)
+		a.Equal("uni/tn-Ent/ctx-prod", res[0].VRF)
+		a.Equal([]Location{
+			{Node: "102", Interface: "eth1/8", Source: "epmIpEp"},
+			{Node: "103", Interface: "eth1/8", Source: "epmIpEp"},
+		}, res[0].Locations)
+	}
+	a.Empty(x.ByIP("bogus"))
+}
+
+func TestByEncap(t *testing.T) {
+	a := assert.New(t)
+	x := newTestIndex(t)
+	a.Len(x.ByEncap("vlan-100"), 2)
+	a.Len(x.ByEncap("101"), 1)
+}
+
+func TestDuplicateIPs(t *testing.T) {
+	a := assert.New(t)
+	x := newTestIndex(t)
+	res := x.DuplicateIPs()
+	if !a.Len(res, 1) {
+		return
+	}
+	a.Equal("10.1.1.10", res[0].IP)
+	a.Equal("uni/tn-Ent/ctx-prod", res[0].VRF)
+	a.Equal([]string{"00:50:56:AA:00:01", "00:50:56:AA:00:03"}, res[0].MACs)
+	a.Len(res[0].Endpoints, 2)
+}
+
+func TestNormalizeMAC(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("00:50:56:AA:BB:CC", NormalizeMAC("0050.56aa.bbcc"))
+	a.Equal("00:50:56:AA:BB:CC", NormalizeMAC("00-50-56-AA-BB-CC"))
+	a.Equal("BOGUS", NormalizeMAC("bogus"))
+}
@@ -0,0 +1,287 @@
+// Package faults summarizes APIC faults and health scores loaded in a mit.DB.
+package faults
+
+import (
+	_ "embed"
+	"sort"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// codeData describes common fault codes.
+//
+//go:embed codes.json
+var codeData string
+
+// Codes maps fault codes to a generic description of the fault.
+var Codes = func() map[string]string {
+	res := map[string]string{}
+	for code, descr := range gjson.Parse(codeData).Map() {
+		res[code] = descr.Str
+	}
+	return res
+}()
+
+// Describe returns the description of a fault code,
+// or an empty string for unknown codes.
+func Describe(code string) string {
+	return Codes[code]
+}
+
+// Severity is a fault severity.
+type Severity string
+
+// Fault severities from highest to lowest
+const (
+	Critical Severity = "critical"
+	Major    Severity = "major"
+	Minor    Severity = "minor"
+	Warning  Severity = "warning"
+	Info     Severity = "info"
+	Cleared  Severity = "cleared"
+)
+
+// Severities lists the fault severities from highest to lowest.
+var Severities = []Severity{Critical, Major, Minor, Warning, Info, Cleared}
+
+// Level ranks a severity, from 5 for critical down to 0 for cleared.
+// Unknown severities are -1.
+func (s Severity) Level() int {
+	for i, sev := range Severities {
+		if s == sev {
+			return len(Severities) - 1 - i
+		}
+	}
+	return -1
+}
+
+// Fault is a fault instance or record.
+type Fault struct {
+	DN string
+	// Affected is the DN of the MO with the fault.
+	Affected  string
+	Code      string
+	Rule      string
+	Severity  Severity
+	Lifecycle string
+	Domain    string
+	Type      string
+	Cause     string
+	Descr     string
+	Acked     bool
+	Created   string
+	// Pod and Node are the IDs of the node with the fault, if any.
+	Pod  string
+	Node string
+	// NodeName is the node's topSystem name, if loaded.
+	NodeName string
+	// Tenant is the name of the tenant with the fault, if any.
+	Tenant string
+	// Ind is the record type of a fault record, e.g. creation or deletion.
+	Ind string
+}
+
+// Cleared reports whether the fault has cleared and is being retained.
+func (f Fault) Cleared() bool {
+	return f.Severity == Cleared || f.Lifecycle == "retaining"
+}
+
+// Description returns the description of the fault code,
+// falling back to the fault's own description.
+func (f Fault) Description() string {
+	if descr := Describe(f.Code); descr != "" {
+		return descr
+	}
+	return f.Descr
+}
+
+// Options filter faults.
+type Options struct {
+	// Acked includes acknowledged faults.
+	Acked bool
+	// Cleared includes cleared faults.
+	Cleared bool
+	// MinSeverity excludes faults below a severity.
+	MinSeverity Severity
+}
+
+// IncludeAcked includes acknowledged faults.
+func IncludeAcked(opts *Options) {
+	opts.Acked = true
+}
+
+// IncludeCleared includes cleared faults.
+func IncludeCleared(opts *Options) {
+	opts.Cleared = true
+}
+
+// MinSeverity excludes faults below a severity.
+func MinSeverity(sev Severity) func(*Options) {
+	return func(opts *Options) {
+		opts.MinSeverity = sev
+	}
+}
+
+// Faults returns the active faultInst MOs sorted by severity and DN.
+// Acknowledged and cleared faults are excluded unless requested, e.g.
+//
+//	faults.Faults(db, faults.IncludeAcked, faults.MinSeverity(faults.Major))
+func Faults(db *mit.DB, mods ...func(*Options)) ([]Fault, error) {
+	opts := Options{}
+	for _, mod := range mods {
+		mod(&opts)
+	}
+	res, err := find(db, "faultInst", func(f Fault) bool {
+		switch {
+		case f.Acked && !opts.Acked:
+			return false
+		case f.Cleared() && !opts.Cleared:
+			return false
+		case opts.MinSeverity != "" && f.Severity.Level() < opts.MinSeverity.Level():
+			return false
+		}
+		return true
+	})
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Severity != b.Severity {
+			return a.Severity.Level() > b.Severity.Level()
+		}
+		return a.DN < b.DN
+	})
+	return res, err
+}
+
+// Records returns the faultRecord history sorted by creation time.
+func Records(db *mit.DB) ([]Fault, error) {
+	res, err := find(db, "faultRecord", func(Fault) bool { return true })
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Created < res[j].Created
+	})
+	return res, err
+}
+
+// find reads the faults of a class that pass a filter.
+func find(db *mit.DB, class string, keep func(Fault) bool) (res []Fault, err error) {
+	names := nodeNames(db)
+	err = db.FindEach(class+":*", func(_ string, v gjson.Result) bool {
+		f := newFault(v)
+		f.NodeName = names[f.Node]
+		if keep(f) {
+			res = append(res, f)
+		}
+		return true
+	})
+	return res, err
+}
+
+// newFault reads a faultInst or faultRecord.
+func newFault(v gjson.Result) Fault {
+	f := Fault{
+		DN:        v.Get("dn").Str,
+		Affected:  v.Get("affected").Str,
+		Code:      v.Get("code").Str,
+		Rule:      v.Get("rule").Str,
+		Severity:  Severity(v.Get("severity").Str),
+		Lifecycle: v.Get("lc").Str,
+		Domain:    v.Get("domain").Str,
+		Type:      v.Get("type").Str,
+		Cause:     v.Get("cause").Str,
+		Descr:     v.Get("descr").Str,
+		Acked:     v.Get("ack").Str == "yes",
+		Created:   v.Get("created").Str,
+		Ind:       v.Get("ind").Str,
+	}
+	if f.Affected == "" {
+		f.Affected = mit.ParentDN(f.DN)
+	}
+	f.Pod, f.Node, _ = mit.NodeDN(f.Affected)
+	f.Tenant, _ = mit.TenantDN(f.Affected)
+	return f
+}
+
+// nodeNames maps node IDs to topSystem names.
+func nodeNames(db *mit.DB) map[string]string {
+	res := map[string]string{}
+	db.FindEach("topSystem:*", func(_ string, v gjson.Result) bool {
+		res[v.Get("id").Str] = v.Get("name").Str
+		return true
+	})
+	return res
+}
+
+// Summary counts faults by attribute.
+type Summary struct {
+	Total       int
+	BySeverity  map[Severity]int
+	ByCode      map[string]int
+	ByLifecycle map[string]int
+	ByDomain    map[string]int
+	// ByNode counts faults by node ID.
+	ByNode   map[string]int
+	ByTenant map[string]int
+}
+
+// Summarize counts faults by severity, code, lifecycle, domain, node and tenant.
+// Faults without a node or tenant are not counted in ByNode or ByTenant.
+func Summarize(faults []Fault) Summary {
+	s := Summary{
+		BySeverity:  map[Severity]int{},
+		ByCode:      map[string]int{},
+		ByLifecycle: map[string]int{},
+		ByDomain:    map[string]int{},
+		ByNode:      map[string]int{},
+		ByTenant:    map[string]int{},
+	}
+	for _, f := range faults {
+		s.Total++
+		s.BySeverity[f.Severity]++
+		s.ByCode[f.Code]++
+		s.ByLifecycle[f.Lifecycle]++
+		s.ByDomain[f.Domain]++
+		if f.Node != "" {
+			s.ByNode[f.Node]++
+		}
+		if f.Tenant != "" {
+			s.ByTenant[f.Tenant]++
+		}
+	}
+	return s
+}
+
+// SummarizeBy summarizes faults per group, e.g. the severities of the faults
+// on each node:
+//
+//	faults.SummarizeBy(res, func(f faults.Fault) string { return f.Node })
+//
+// Faults with an empty key are not counted.
+func SummarizeBy(faults []Fault, key func(Fault) string) map[string]Summary {
+	groups := map[string][]Fault{}
+	for _, f := range faults {
+		if k := key(f); k != "" {
+			groups[k] = append(groups[k], f)
+		}
+	}
+	res := map[string]Summary{}
+	for k, group := range groups {
+		res[k] = Summarize(group)
+	}
+	return res
+}
+
+// TopCodes returns the fault codes in the summary, most frequent first.
+func (s Summary) TopCodes() []string {
+	var res []string
+	for code := range s.ByCode {
+		res = append(res, code)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if s.ByCode[res[i]] != s.ByCode[res[j]] {
+			return s.ByCode[res[i]] > s.ByCode[res[j]]
+		}
+		return res[i] < res[j]
+	})
+	return res
+}
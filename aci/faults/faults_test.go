@@ -0,0 +1,93 @@
+package faults
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaults(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+
+	// Acked and cleared faults are excluded by default
+	res, err := Faults(db)
+	a.NoError(err)
+	a.Len(res, 3)
+	a.Equal("F0532", res[0].Code)
+	a.Equal(Major, res[0].Severity)
+	a.Equal("topology/pod-1/node-101/sys/phys-[eth1/1]/phys", res[0].Affected)
+	a.Equal("1", res[0].Pod)
+	a.Equal("101", res[0].Node)
+	a.Equal("leaf-101", res[0].NodeName)
+	a.Equal("Enterprise", res[1].Tenant)
+	a.Empty(res[1].Node)
+
+	res, err = Faults(db, IncludeAcked, IncludeCleared)
+	a.NoError(err)
+	a.Len(res, 5)
+	a.True(res[4].Cleared())
+	a.Equal("201", res[4].Node)
+
+	res, err = Faults(db, IncludeAcked, MinSeverity(Warning))
+	a.NoError(err)
+	a.Len(res, 4)
+	res, err = Faults(db, MinSeverity(Major))
+	a.NoError(err)
+	a.Len(res, 1)
+}
+
+func TestRecords(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := Records(db)
+	a.NoError(err)
+	a.Len(res, 2)
+	a.Equal("creation", res[0].Ind)
+	a.Equal("topology/pod-1/node-201/sys/ch/p-[/bootflash]-f-[/dev/sda1]", res[0].Affected)
+	a.Equal("201", res[0].Node)
+	a.Equal("deletion", res[1].Ind)
+}
+
+func TestSummarize(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := Faults(db, IncludeAcked, IncludeCleared)
+	a.NoError(err)
+	s := Summarize(res)
+	a.Equal(5, s.Total)
+	a.Equal(2, s.BySeverity[Minor])
+	a.Equal(1, s.BySeverity[Cleared])
+	a.Equal(2, s.ByCode["F0467"])
+	a.Equal(3, s.ByLifecycle["raised"])
+	a.Equal(2, s.ByDomain["tenant"])
+	a.Equal(map[string]int{"101": 1, "102": 1, "201": 1}, s.ByNode)
+	a.Equal(map[string]int{"Enterprise": 2}, s.ByTenant)
+	a.Equal("F0467", s.TopCodes()[0])
+
+	// Per group
+	byNode := SummarizeBy(res, func(f Fault) string { return f.Node })
+	a.Len(byNode, 3)
+	a.Equal(map[Severity]int{Major: 1}, byNode["101"].BySeverity)
+	a.Equal(map[string]int{"F0546": 1}, byNode["102"].ByCode)
+	a.Equal(map[string]int{"retaining": 1}, byNode["201"].ByLifecycle)
+	byTenant := SummarizeBy(res, func(f Fault) string { return f.Tenant })
+	a.Equal(2, byTenant["Enterprise"].Total)
+	a.Equal(map[string]int{"raised": 1, "soaking": 1}, byTenant["Enterprise"].ByLifecycle)
+	byDomain := SummarizeBy(res, func(f Fault) string { return f.Domain })
+	a.Equal(map[Severity]int{Major: 1, Warning: 1}, byDomain["access"].BySeverity)
+	a.Equal(map[string]int{"F1527": 1}, byDomain["infra"].ByCode)
+}
+
+func TestDescribe(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("Port is down and used by an EPG", Describe("F0532"))
+	a.Empty(Describe("F9999"))
+	f := Fault{Code: "F9999", Descr: "custom"}
+	a.Equal("custom", f.Description())
+	a.True(Critical.Level() > Major.Level())
+	a.Equal(0, Cleared.Level())
+	a.Equal(-1, Severity("bogus").Level())
+}
@@ -0,0 +1,92 @@
+package faults
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Score is the health score of an MO.
+type Score struct {
+	// DN is the MO with the score, i.e. the parent of the healthInst.
+	DN     string
+	Cur    int
+	Prev   int
+	MaxSev Severity
+}
+
+// Scores returns the healthInst scores sorted by DN.
+func Scores(db *mit.DB) (res []Score, err error) {
+	err = db.FindEach("healthInst:*", func(_ string, v gjson.Result) bool {
+		res = append(res, newScore(v))
+		return true
+	})
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].DN < res[j].DN
+	})
+	return res, err
+}
+
+func newScore(v gjson.Result) Score {
+	return Score{
+		DN:     mit.ParentDN(v.Get("dn").Str),
+		Cur:    int(v.Get("cur").Int()),
+		Prev:   int(v.Get("prev").Int()),
+		MaxSev: Severity(v.Get("maxSev").Str),
+	}
+}
+
+// Health returns the score of an MO, or of its nearest ancestor with a score,
+// e.g. the EPG score for an EPG's static path.
+func Health(db *mit.DB, dn string) (Score, error) {
+	for d := dn; d != ""; d = mit.ParentDN(d) {
+		v, err := db.Get("healthInst:%s/health", d)
+		if err == nil {
+			return newScore(v), nil
+		}
+		if !errors.Is(err, mit.ErrNotFound) {
+			return Score{}, err
+		}
+	}
+	return Score{}, fmt.Errorf("HEALTH:%s:%w", dn, mit.ErrNotFound)
+}
+
+// Rollup aggregates the scores of an MO and its descendants.
+type Rollup struct {
+	DN string
+	// Count is the number of scores in the subtree.
+	Count int
+	// Min is the lowest score and Worst the DN with that score.
+	Min   int
+	Worst string
+	// Avg is the average score.
+	Avg float64
+}
+
+// Rollups aggregates scores following DN ancestry, e.g. the EPG scores of a
+// tenant roll up to the tenant and to uni. Rollups are keyed by DN.
+func Rollups(scores []Score) map[string]Rollup {
+	res := map[string]Rollup{}
+	sums := map[string]int{}
+	for _, score := range scores {
+		rns := mit.SplitDN(score.DN)
+		for i := 1; i <= len(rns); i++ {
+			dn := strings.Join(rns[:i], "/")
+			r, ok := res[dn]
+			if !ok || score.Cur < r.Min {
+				r.Min, r.Worst = score.Cur, score.DN
+			}
+			r.DN = dn
+			r.Count++
+			sums[dn] += score.Cur
+			r.Avg = float64(sums[dn]) / float64(r.Count)
+			res[dn] = r
+		}
+	}
+	return res
+}
@@ -0,0 +1,58 @@
+package faults
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScores(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := Scores(db)
+	a.NoError(err)
+	// 3 tenant scores and 3 node scores from topSystem children
+	a.Len(res, 6)
+	a.Equal("topology/pod-1/node-101/sys", res[0].DN)
+	a.Equal(98, res[0].Cur)
+	a.Equal(78, res[0].Prev)
+	a.Equal(Cleared, res[0].MaxSev)
+}
+
+func TestHealth(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	score, err := Health(db, "uni/tn-Enterprise/ap-web/epg-app/rspathAtt-[topology/pod-1/paths-101/pathep-[eth1/1]]")
+	a.NoError(err)
+	a.Equal("uni/tn-Enterprise/ap-web/epg-app", score.DN)
+	a.Equal(70, score.Cur)
+
+	score, err = Health(db, "uni/tn-Enterprise/BD-bd1")
+	a.NoError(err)
+	a.Equal("uni/tn-Enterprise", score.DN)
+
+	_, err = Health(db, "uni/infra")
+	a.ErrorIs(err, mit.ErrNotFound)
+}
+
+func TestRollups(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	scores, err := Scores(db)
+	a.NoError(err)
+	res := Rollups(scores)
+
+	tenant := res["uni/tn-Enterprise"]
+	a.Equal(3, tenant.Count)
+	a.Equal(70, tenant.Min)
+	a.Equal("uni/tn-Enterprise/ap-web/epg-app", tenant.Worst)
+	a.InDelta(86.67, tenant.Avg, 0.01)
+
+	pod := res["topology/pod-1"]
+	a.Equal(3, pod.Count)
+	a.Equal(98, pod.Min)
+	a.Equal(2, res["uni/tn-Enterprise/ap-web"].Count)
+}
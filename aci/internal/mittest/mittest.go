@@ -0,0 +1,20 @@
+// Package mittest creates databases from test fixtures.
+package mittest
+
+import (
+	"testing"
+
+	"lib/aci/mit"
+)
+
+// Folder loads the class files in a folder, e.g. testdata. The database is
+// closed when the test ends.
+func Folder(t testing.TB, dir string) *mit.DB {
+	t.Helper()
+	db, err := mit.New(mit.NewFolderSource(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &db
+}
@@ -0,0 +1,178 @@
+// Package inventory reports the firmware and hardware of fabric nodes.
+package inventory
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// slotDN matches the slot of a module, e.g. sys/ch/lcslot-1/lc,
+// sys/ch/psuslot-2/psu or sys/ch/ftslot-1/ft/fan-2.
+var slotDN = regexp.MustCompile(`/(?:lc|sup|psu|ft)slot-(\d+)/[a-z]+(?:/fan-(\d+))?$`)
+
+// Module kinds
+const (
+	Linecard = "linecard"
+	PSU      = "psu"
+	Fan      = "fan"
+)
+
+// Module is a linecard, power supply or fan of a node.
+type Module struct {
+	DN   string
+	Kind string
+	// Slot is the slot number, or tray/fan for fans, e.g. 1/2.
+	Slot   string
+	Model  string
+	Serial string
+	State  string
+}
+
+// Node is the inventory record of a controller or switch.
+type Node struct {
+	ID   string
+	Pod  string
+	Name string
+	Role string
+	// Model and Serial are from the chassis, or topSystem if not loaded.
+	Model  string
+	Serial string
+	// Version is the running firmware, e.g. 5.2(7f) or n9000-15.2(7f).
+	Version string
+	Uptime  time.Duration
+	OOB     string
+	Modules []Module
+}
+
+// Nodes joins topSystem, firmwareRunning, firmwareCtrlrRunning, eqptCh,
+// eqptLC, eqptPsu and eqptFan into per-node records, sorted by pod and ID.
+func Nodes(db *mit.DB) ([]*Node, error) {
+	nodes := map[string]*Node{}
+	node := func(dn string) *Node {
+		pod, id, ok := mit.NodeDN(dn)
+		if !ok {
+			return nil
+		}
+		n, ok := nodes[id]
+		if !ok {
+			n = &Node{ID: id, Pod: pod}
+			nodes[id] = n
+		}
+		return n
+	}
+	module := func(kind string) func(n *Node, v gjson.Result) {
+		return func(n *Node, v gjson.Result) {
+			m := Module{
+				DN:     v.Get("dn").Str,
+				Kind:   kind,
+				Model:  v.Get("model").Str,
+				Serial: v.Get("ser").Str,
+				State:  v.Get("operSt").Str,
+			}
+			if s := slotDN.FindStringSubmatch(m.DN); s != nil {
+				m.Slot = s[1]
+				if s[2] != "" {
+					m.Slot += "/" + s[2]
+				}
+			}
+			n.Modules = append(n.Modules, m)
+		}
+	}
+	steps := []struct {
+		class string
+		fn    func(n *Node, v gjson.Result)
+	}{
+		{"topSystem", addTopSystem},
+		{"firmwareRunning", addFirmware},
+		{"firmwareCtrlrRunning", addFirmware},
+		{"eqptCh", addChassis},
+		{"eqptLC", module(Linecard)},
+		{"eqptPsu", module(PSU)},
+		{"eqptFan", module(Fan)},
+	}
+	for _, step := range steps {
+		err := db.FindEach(step.class+":*", func(_ string, v gjson.Result) bool {
+			if n := node(v.Get("dn").Str); n != nil {
+				step.fn(n, v)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		sort.SliceStable(n.Modules, func(i, j int) bool {
+			a, b := n.Modules[i], n.Modules[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			return a.DN < b.DN
+		})
+		res = append(res, n)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Pod != res[j].Pod {
+			return mit.LessNumeric(res[i].Pod, res[j].Pod)
+		}
+		return mit.LessNumeric(res[i].ID, res[j].ID)
+	})
+	return res, nil
+}
+
+func addTopSystem(n *Node, v gjson.Result) {
+	n.Name = v.Get("name").Str
+	n.Role = v.Get("role").Str
+	n.OOB = v.Get("oobMgmtAddr").Str
+	n.Uptime = parseUptime(v.Get("systemUpTime").Str)
+	if n.Serial == "" {
+		n.Serial = v.Get("serial").Str
+	}
+	if n.Version == "" {
+		n.Version = v.Get("version").Str
+	}
+}
+
+func addFirmware(n *Node, v gjson.Result) {
+	if version := v.Get("version").Str; version != "" {
+		n.Version = version
+	}
+}
+
+func addChassis(n *Node, v gjson.Result) {
+	n.Model = v.Get("model").Str
+	if serial := v.Get("ser").Str; serial != "" {
+		n.Serial = serial
+	}
+}
+
+// parseUptime parses a topSystem uptime, e.g. 12:03:25:41.000 for 12 days,
+// 3 hours, 25 minutes and 41 seconds. Invalid uptimes are 0.
+func parseUptime(s string) time.Duration {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 {
+		return 0
+	}
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+	var d time.Duration
+	for i, unit := range units {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(n) * unit
+	}
+	sec, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil {
+		return 0
+	}
+	return d + time.Duration(sec*float64(time.Second))
+}
@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"lib/aci/internal/mittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNodes(t *testing.T) []*Node {
+	db := mittest.Folder(t, "testdata")
+	nodes, err := Nodes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
+
+func TestNodes(t *testing.T) {
+	a := assert.New(t)
+	nodes := newTestNodes(t)
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	a.Equal([]string{"1", "2", "101", "102", "201", "1101"}, ids)
+
+	leaf := nodes[2]
+	a.Equal("1", leaf.Pod)
+	a.Equal("leaf-101", leaf.Name)
+	a.Equal("leaf", leaf.Role)
+	a.Equal("N9K-C93180YC-FX", leaf.Model)
+	a.Equal("FDO101CH", leaf.Serial)
+	a.Equal("n9000-15.2(7f)", leaf.Version)
+	a.Equal("192.168.0.101", leaf.OOB)
+	a.Equal(12*24*time.Hour+3*time.Hour+25*time.Minute+41*time.Second, leaf.Uptime)
+	a.Equal([]Module{
+		{DN: "topology/pod-1/node-101/sys/ch/ftslot-1/ft/fan-1", Kind: Fan, Slot: "1/1", State: "ok"},
+		{DN: "topology/pod-1/node-101/sys/ch/ftslot-1/ft/fan-2", Kind: Fan, Slot: "1/2", State: "ok"},
+		{DN: "topology/pod-1/node-101/sys/ch/psuslot-1/psu", Kind: PSU, Slot: "1", Model: "NXA-PAC-500W-PE", Serial: "ART101", State: "ok"},
+		{DN: "topology/pod-1/node-101/sys/ch/psuslot-2/psu", Kind: PSU, Slot: "2", Model: "NXA-PAC-500W-PE", Serial: "ART102", State: "shut"},
+	}, leaf.Modules)
+
+	spine := nodes[4]
+	a.Equal("N9K-C9504", spine.Model)
+	if a.Len(spine.Modules, 2) {
+		a.Equal(Linecard, spine.Modules[0].Kind)
+		a.Equal("2", spine.Modules[1].Slot)
+	}
+
+	// Without firmware or chassis records topSystem is used
+	remote := nodes[5]
+	a.Equal("2", remote.Pod)
+	a.Equal("n9000-15.2(7f)", remote.Version)
+	a.Equal("FDO1101", remote.Serial)
+	a.Empty(remote.Model)
+
+	a.Equal("5.2(7f)", nodes[0].Version)
+}
+
+func TestMismatches(t *testing.T) {
+	a := assert.New(t)
+	nodes := newTestNodes(t)
+	a.Equal([]Mismatch{{
+		Group: "switch",
+		Versions: map[string][]string{
+			"15.2(7f)": {"101", "201", "1101"},
+			"15.2(8d)": {"102"},
+		},
+	}}, Mismatches(nodes))
+
+	nodes[1].Version = "5.2(8d)"
+	res := Mismatches(nodes)
+	if a.Len(res, 2) {
+		a.Equal("controller", res[0].Group)
+		a.Equal([]string{"2"}, res[0].Versions["5.2(8d)"])
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(90*time.Minute+500*time.Millisecond, parseUptime("00:01:30:00.500"))
+	a.Zero(parseUptime(""))
+	a.Zero(parseUptime("a:b:c:d"))
+}
@@ -0,0 +1,60 @@
+package inventory
+
+import (
+	"sort"
+	"strings"
+)
+
+// Mismatch is a group of nodes running more than one version.
+type Mismatch struct {
+	// Group is controller or switch.
+	Group string
+	// Versions maps each version to the IDs of the nodes running it.
+	Versions map[string][]string
+}
+
+// group returns the version group of a role: controllers are compared with
+// each other, and leaves and spines as switches.
+func group(role string) string {
+	if role == "controller" {
+		return "controller"
+	}
+	return "switch"
+}
+
+// switchVersion strips the platform prefix from a switch version,
+// e.g. n9000-15.2(7f) to 15.2(7f).
+func switchVersion(version string) string {
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[i+1:]
+	}
+	return version
+}
+
+// Mismatches returns the groups of nodes not all running the same version.
+// Nodes without a version are ignored.
+func Mismatches(nodes []*Node) []Mismatch {
+	versions := map[string]map[string][]string{}
+	for _, n := range nodes {
+		if n.Version == "" {
+			continue
+		}
+		g := group(n.Role)
+		version := n.Version
+		if g == "switch" {
+			version = switchVersion(version)
+		}
+		if versions[g] == nil {
+			versions[g] = map[string][]string{}
+		}
+		versions[g][version] = append(versions[g][version], n.ID)
+	}
+	var res []Mismatch
+	for g, byVersion := range versions {
+		if len(byVersion) > 1 {
+			res = append(res, Mismatch{Group: g, Versions: byVersion})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Group < res[j].Group })
+	return res
+}
@@ -0,0 +1,178 @@
+package mit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/brightpuddle/goaci"
+)
+
+// APICSource is a live APIC source.
+//
+// Used to query classes directly from an APIC with a goaci client.
+// The client should already be logged in.
+type APICSource struct {
+	Client  *goaci.Client
+	Classes []string
+	// PageSize is the number of MOs per request; 0 disables paging.
+	PageSize int
+	// Params holds extra query parameters, e.g. rsp-subtree=full
+	Params url.Values
+	// Retries is the number of times a request is retried after a server
+	// error, timeout or connection error.
+	Retries int
+	// RetryDelay is the wait between retries.
+	RetryDelay time.Duration
+}
+
+// NewAPICSource creates a new source for querying classes from an APIC.
+// Pass modifiers to change the query behavior, e.g.
+//
+//	NewAPICSource(&client, []string{"fvTenant"}, PageSize(1000), Query("rsp-subtree", "full"))
+func NewAPICSource(client *goaci.Client, classes []string, mods ...func(*APICSource)) *APICSource {
+	src := &APICSource{
+		Client:     client,
+		Classes:    classes,
+		Params:     url.Values{},
+		Retries:    2,
+		RetryDelay: time.Second,
+	}
+	for _, mod := range mods {
+		mod(src)
+	}
+	return src
+}
+
+// PageSize sets the number of MOs per request.
+func PageSize(n int) func(*APICSource) {
+	return func(src *APICSource) {
+		src.PageSize = n
+	}
+}
+
+// Query adds a query parameter to every request, e.g. rsp-subtree-include.
+func Query(k, v string) func(*APICSource) {
+	return func(src *APICSource) {
+		if src.Params == nil {
+			src.Params = url.Values{}
+		}
+		src.Params.Add(k, v)
+	}
+}
+
+// Retry sets the number of retries and the wait between them.
+func Retry(n int, delay time.Duration) func(*APICSource) {
+	return func(src *APICSource) {
+		src.Retries = n
+		src.RetryDelay = delay
+	}
+}
+
+// Entries fulfills the Source interface.
+//
+// Each class is a single entry. Pages are requested as the entry is read.
+func (src *APICSource) Entries() ([]*Entry, error) {
+	if src.Client == nil {
+		return nil, fmt.Errorf("APIC source has no client")
+	}
+	var entries []*Entry
+	for _, class := range src.Classes {
+		entry := &Entry{
+			Class: class,
+			Open: func() (io.ReadCloser, error) {
+				r, w := io.Pipe()
+				go func() {
+					w.CloseWithError(src.write(w, class))
+				}()
+				return r, nil
+			},
+		}
+		entry.Read = entry.Body
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// get requests a page of a class, retrying transient failures.
+func (src *APICSource) get(class string, page int) (res goaci.Res, err error) {
+	var mods []func(*goaci.Req)
+	for k, vals := range src.Params {
+		for _, v := range vals {
+			mods = append(mods, goaci.Query(k, v))
+		}
+	}
+	if src.PageSize > 0 {
+		mods = append(mods,
+			goaci.Query("page", strconv.Itoa(page)),
+			goaci.Query("page-size", strconv.Itoa(src.PageSize)),
+		)
+		// Paging is only consistent with a stable sort order
+		if src.Params.Get("order-by") == "" {
+			mods = append(mods, goaci.Query("order-by", class+".dn"))
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		res, err = src.Client.Get("/api/class/"+class, mods...)
+		if err == nil || attempt >= src.Retries || !transient(err) {
+			break
+		}
+		time.Sleep(src.RetryDelay)
+	}
+	if err != nil {
+		return res, fmt.Errorf("cannot query %s page %d: %v", class, page, err)
+	}
+	if errText := res.Get("imdata.0.error.attributes.text").Str; errText != "" {
+		return res, fmt.Errorf("cannot query %s page %d: %s", class, page, errText)
+	}
+	return res, nil
+}
+
+// transient reports whether a failed request may succeed if retried, i.e. on
+// a 5xx status, timeout or connection error. Client errors, e.g. a 400 for an
+// unknown class, are returned immediately.
+func transient(err error) bool {
+	// goaci only reports the status in the error text
+	var status int
+	if _, scanErr := fmt.Sscanf(err.Error(), "received HTTP status %d", &status); scanErr == nil {
+		return status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// write streams all pages of a class as a single imdata response.
+func (src *APICSource) write(w io.Writer, class string) error {
+	if _, err := io.WriteString(w, `{"imdata":[`); err != nil {
+		return err
+	}
+	count, total := 0, 0
+	for page := 0; ; page++ {
+		res, err := src.get(class, page)
+		if err != nil {
+			return err
+		}
+		total = int(res.Get("totalCount").Int())
+		imdata := res.Get("imdata").Array()
+		for _, mo := range imdata {
+			if count > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, mo.Raw); err != nil {
+				return err
+			}
+			count++
+		}
+		if src.PageSize <= 0 || len(imdata) < src.PageSize || count >= total {
+			break
+		}
+	}
+	_, err := fmt.Fprintf(w, `],"totalCount":"%d"}`, total)
+	return err
+}
@@ -0,0 +1,105 @@
+package mit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brightpuddle/goaci"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// newTestAPIC starts a stand-in APIC serving tenants with paging.
+// The first class request fails to exercise retries, and fvBD requests are
+// client errors.
+func newTestAPIC(t *testing.T, tenants int) (*httptest.Server, *int32, *int32) {
+	var requests, bdRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/aaaLogin.json", "/api/aaaRefresh.json":
+			fmt.Fprint(w, `{"imdata":[{"aaaLogin":{"attributes":{"token":"x"}}}]}`)
+		case "/api/class/fvTenant.json":
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			q := r.URL.Query()
+			page, _ := strconv.Atoi(q.Get("page"))
+			size, _ := strconv.Atoi(q.Get("page-size"))
+			if size == 0 {
+				size = tenants
+			}
+			var mos []string
+			for i := page * size; i < (page+1)*size && i < tenants; i++ {
+				mos = append(mos, fmt.Sprintf(
+					`{"fvTenant":{"attributes":{"dn":"uni/tn-t%d","name":"t%d","subtree":%q}}}`,
+					i, i, q.Get("rsp-subtree")))
+			}
+			fmt.Fprintf(w, `{"totalCount":"%d","imdata":[%s]}`, tenants, strings.Join(mos, ","))
+		case "/api/class/fvBD.json":
+			atomic.AddInt32(&bdRequests, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &bdRequests
+}
+
+func TestAPICSource(t *testing.T) {
+	a := assert.New(t)
+	srv, requests, bdRequests := newTestAPIC(t, 5)
+	client, _ := goaci.NewClient(srv.URL, "admin", "password")
+	a.NoError(client.Login())
+
+	src := NewAPICSource(&client, []string{"fvTenant"},
+		PageSize(2),
+		Query("rsp-subtree", "full"),
+		Retry(1, time.Millisecond),
+	)
+	db, err := New(src)
+	a.NoError(err)
+	defer db.Close()
+
+	res, err := db.Find("fvTenant:*")
+	a.NoError(err)
+	a.Len(res, 5)
+	a.Equal("full", res[0].Get("subtree").Str)
+	// One failed request and three pages
+	a.Equal(int32(4), atomic.LoadInt32(requests))
+
+	// Entries can still be read whole
+	entries, err := src.Entries()
+	a.NoError(err)
+	body, err := entries[0].Read()
+	a.NoError(err)
+	a.Equal(`"5"`, gjson.GetBytes(body, "totalCount").Raw)
+
+	// Client errors are not retried
+	src = NewAPICSource(&client, []string{"fvBD"}, Retry(1, time.Millisecond))
+	_, err = New(src)
+	a.Error(err)
+	a.Equal(int32(1), atomic.LoadInt32(bdRequests))
+}
+
+func TestTransient(t *testing.T) {
+	a := assert.New(t)
+	a.True(transient(fmt.Errorf("received HTTP status %d", http.StatusServiceUnavailable)))
+	a.False(transient(fmt.Errorf("received HTTP status %d", http.StatusBadRequest)))
+	a.False(transient(fmt.Errorf("received HTTP status %d", http.StatusNotFound)))
+	a.False(transient(errors.New("authentication error")))
+
+	// Connection errors
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	_, err := http.Get(srv.URL)
+	a.True(transient(err))
+}
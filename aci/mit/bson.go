@@ -0,0 +1,372 @@
+package mit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxBSONSize is the largest BSON document accepted, i.e. the MongoDB limit plus headroom.
+const maxBSONSize = 64 << 20
+
+// errBSON is returned for malformed BSON data
+var errBSON = errors.New("invalid BSON document")
+
+// streamBSON streams a BSON dump, e.g. from mongodump, calling fn for each
+// document converted to relaxed extended JSON.
+func streamBSON(r io.Reader, fn func(doc gjson.Result) error) error {
+	br := bufio.NewReader(r)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		n := binary.LittleEndian.Uint32(size[:])
+		if n < 5 || n > maxBSONSize {
+			return errBSON
+		}
+		doc := make([]byte, n)
+		copy(doc, size[:])
+		if _, err := io.ReadFull(br, doc[4:]); err != nil {
+			return err
+		}
+		j, err := bsonToJSON(doc)
+		if err != nil {
+			return err
+		}
+		if err := fn(gjson.Parse(j)); err != nil {
+			return err
+		}
+	}
+}
+
+// bsonToJSON converts a BSON document to relaxed extended JSON.
+func bsonToJSON(doc []byte) (string, error) {
+	d := bsonDecoder{buf: doc}
+	var out bytes.Buffer
+	if err := d.document(&out, false); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// bsonDecoder reads BSON values from a buffer.
+type bsonDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *bsonDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errBSON
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *bsonDecoder) int32() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (d *bsonDecoder) int64() (int64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (d *bsonDecoder) cstring() (string, error) {
+	i := bytes.IndexByte(d.buf[d.pos:], 0)
+	if i < 0 {
+		return "", errBSON
+	}
+	s := string(d.buf[d.pos : d.pos+i])
+	d.pos += i + 1
+	return s, nil
+}
+
+func (d *bsonDecoder) string() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.next(int(n))
+	if err != nil || n < 1 || b[n-1] != 0 {
+		return "", errBSON
+	}
+	return string(b[:n-1]), nil
+}
+
+// document writes an embedded document or array as JSON.
+func (d *bsonDecoder) document(out *bytes.Buffer, array bool) error {
+	start := d.pos
+	n, err := d.int32()
+	if err != nil {
+		return err
+	}
+	end := start + int(n)
+	if n < 5 || end > len(d.buf) || d.buf[end-1] != 0 {
+		return errBSON
+	}
+	open, close := byte('{'), byte('}')
+	if array {
+		open, close = '[', ']'
+	}
+	out.WriteByte(open)
+	for first := true; d.pos < end-1; first = false {
+		typ := d.buf[d.pos]
+		d.pos++
+		key, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		if !first {
+			out.WriteByte(',')
+		}
+		if !array {
+			writeJSONString(out, key)
+			out.WriteByte(':')
+		}
+		if err := d.value(out, typ); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	d.pos = end
+	out.WriteByte(close)
+	return nil
+}
+
+// value writes a single BSON value as JSON.
+func (d *bsonDecoder) value(out *bytes.Buffer, typ byte) error {
+	switch typ {
+	case 0x01: // double
+		b, err := d.next(8)
+		if err != nil {
+			return err
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(b))
+		switch {
+		case math.IsNaN(f):
+			out.WriteString(`{"$numberDouble":"NaN"}`)
+		case math.IsInf(f, 1):
+			out.WriteString(`{"$numberDouble":"Infinity"}`)
+		case math.IsInf(f, -1):
+			out.WriteString(`{"$numberDouble":"-Infinity"}`)
+		default:
+			s := strconv.FormatFloat(f, 'g', -1, 64)
+			if !strings.ContainsAny(s, ".eE") {
+				s += ".0"
+			}
+			out.WriteString(s)
+		}
+	case 0x02: // string
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		writeJSONString(out, s)
+	case 0x03: // document
+		return d.document(out, false)
+	case 0x04: // array
+		return d.document(out, true)
+	case 0x05: // binary
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		sub, err := d.next(1)
+		if err != nil {
+			return err
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `{"$binary":{"base64":"%s","subType":"%02x"}}`,
+			base64.StdEncoding.EncodeToString(b), sub[0])
+	case 0x06: // undefined
+		out.WriteString(`{"$undefined":true}`)
+	case 0x07: // ObjectId
+		b, err := d.next(12)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `{"$oid":"%s"}`, hex.EncodeToString(b))
+	case 0x08: // bool
+		b, err := d.next(1)
+		if err != nil {
+			return err
+		}
+		out.WriteString(strconv.FormatBool(b[0] != 0))
+	case 0x09: // UTC datetime
+		ms, err := d.int64()
+		if err != nil {
+			return err
+		}
+		t := time.UnixMilli(ms).UTC()
+		if t.Year() < 1970 || t.Year() > 9999 {
+			fmt.Fprintf(out, `{"$date":{"$numberLong":"%d"}}`, ms)
+		} else {
+			fmt.Fprintf(out, `{"$date":"%s"}`, t.Format("2006-01-02T15:04:05.000Z07:00"))
+		}
+	case 0x0A: // null
+		out.WriteString("null")
+	case 0x0B: // regex
+		pattern, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		options, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		out.WriteString(`{"$regularExpression":{"pattern":`)
+		writeJSONString(out, pattern)
+		out.WriteString(`,"options":`)
+		writeJSONString(out, options)
+		out.WriteString(`}}`)
+	case 0x0C: // DBPointer
+		ref, err := d.string()
+		if err != nil {
+			return err
+		}
+		b, err := d.next(12)
+		if err != nil {
+			return err
+		}
+		out.WriteString(`{"$dbPointer":{"$ref":`)
+		writeJSONString(out, ref)
+		fmt.Fprintf(out, `,"$id":{"$oid":"%s"}}}`, hex.EncodeToString(b))
+	case 0x0D, 0x0E: // JavaScript code, symbol
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		key := "$code"
+		if typ == 0x0E {
+			key = "$symbol"
+		}
+		fmt.Fprintf(out, `{"%s":`, key)
+		writeJSONString(out, s)
+		out.WriteByte('}')
+	case 0x0F: // JavaScript code with scope
+		if _, err := d.int32(); err != nil {
+			return err
+		}
+		code, err := d.string()
+		if err != nil {
+			return err
+		}
+		out.WriteString(`{"$code":`)
+		writeJSONString(out, code)
+		out.WriteString(`,"$scope":`)
+		if err := d.document(out, false); err != nil {
+			return err
+		}
+		out.WriteByte('}')
+	case 0x10: // int32
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		out.WriteString(strconv.Itoa(int(n)))
+	case 0x11: // timestamp
+		b, err := d.next(8)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `{"$timestamp":{"t":%d,"i":%d}}`,
+			binary.LittleEndian.Uint32(b[4:]), binary.LittleEndian.Uint32(b[:4]))
+	case 0x12: // int64
+		n, err := d.int64()
+		if err != nil {
+			return err
+		}
+		out.WriteString(strconv.FormatInt(n, 10))
+	case 0x13: // decimal128
+		b, err := d.next(16)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `{"$numberDecimal":"%s"}`, decimal128(b))
+	case 0xFF:
+		out.WriteString(`{"$minKey":1}`)
+	case 0x7F:
+		out.WriteString(`{"$maxKey":1}`)
+	default:
+		return fmt.Errorf("unsupported BSON type 0x%02x", typ)
+	}
+	return nil
+}
+
+// writeJSONString writes a quoted JSON string.
+func writeJSONString(out *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	out.Write(b)
+}
+
+// decimal128 formats an IEEE 754-2008 BID encoded decimal128.
+func decimal128(b []byte) string {
+	lo := binary.LittleEndian.Uint64(b[:8])
+	hi := binary.LittleEndian.Uint64(b[8:])
+	sign := ""
+	if hi>>63 == 1 {
+		sign = "-"
+	}
+	var exp int
+	coef := new(big.Int)
+	switch {
+	case hi>>58&0x1f == 0x1f:
+		return "NaN"
+	case hi>>58&0x1f == 0x1e:
+		return sign + "Infinity"
+	case hi>>61&0x3 == 0x3:
+		// The coefficient is larger than the max, i.e. zero
+		exp = int(hi>>47&0x3fff) - 6176
+	default:
+		exp = int(hi>>49&0x3fff) - 6176
+		coef.SetUint64(hi & (1<<49 - 1))
+		coef.Lsh(coef, 64)
+		coef.Or(coef, new(big.Int).SetUint64(lo))
+	}
+	digits := coef.String()
+	adjusted := exp + len(digits) - 1
+	if exp > 0 || adjusted < -6 {
+		// Scientific notation
+		s := digits[:1]
+		if len(digits) > 1 {
+			s += "." + digits[1:]
+		}
+		return fmt.Sprintf("%s%sE%+d", sign, s, adjusted)
+	}
+	if exp == 0 {
+		return sign + digits
+	}
+	// Plain notation with a decimal point
+	point := len(digits) + exp
+	if point <= 0 {
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	}
+	return sign + digits[:point] + "." + digits[point:]
+}
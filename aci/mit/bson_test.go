@@ -0,0 +1,111 @@
+package mit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// bsonDoc builds a BSON document from raw elements.
+func bsonDoc(elems ...[]byte) []byte {
+	body := append(bytes.Join(elems, nil), 0)
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(body)+4))
+	return append(size, body...)
+}
+
+// bsonElem builds a BSON element.
+func bsonElem(typ byte, key string, value []byte) []byte {
+	return append(append([]byte{typ}, append([]byte(key), 0)...), value...)
+}
+
+func bsonString(s string) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(len(s)+1))
+	return append(append(b, s...), 0)
+}
+
+func bsonInt64(n int64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(n))
+	return b
+}
+
+func TestBSONToJSON(t *testing.T) {
+	a := assert.New(t)
+	oid, _ := hex.DecodeString("5ee97b394174748e7dd74eb8")
+	doc := bsonDoc(
+		bsonElem(0x07, "_id", oid),
+		bsonElem(0x02, "name", bsonString(`a "quoted" name`)),
+		bsonElem(0x01, "ratio", bsonInt64(0x3fe0000000000000)),
+		bsonElem(0x10, "small", []byte{7, 0, 0, 0}),
+		bsonElem(0x12, "big", bsonInt64(1<<40)),
+		bsonElem(0x09, "created", bsonInt64(1592338425123)),
+		bsonElem(0x08, "enabled", []byte{1}),
+		bsonElem(0x0A, "none", nil),
+		bsonElem(0x04, "tags", bsonDoc(
+			bsonElem(0x02, "0", bsonString("x")),
+			bsonElem(0x03, "1", bsonDoc(bsonElem(0x02, "k", bsonString("v")))),
+		)),
+		bsonElem(0x05, "bin", append([]byte{2, 0, 0, 0, 0}, 'h', 'i')),
+		bsonElem(0x0B, "re", []byte("^a\x00i\x00")),
+	)
+	j, err := bsonToJSON(doc)
+	a.NoError(err)
+	a.Equal(`{"_id":{"$oid":"5ee97b394174748e7dd74eb8"},"name":"a \"quoted\" name","ratio":0.5,`+
+		`"small":7,"big":1099511627776,"created":{"$date":"2020-06-16T20:13:45.123Z"},`+
+		`"enabled":true,"none":null,"tags":["x",{"k":"v"}],`+
+		`"bin":{"$binary":{"base64":"aGk=","subType":"00"}},`+
+		`"re":{"$regularExpression":{"pattern":"^a","options":"i"}}}`, j)
+	a.True(gjson.Valid(j))
+
+	// Truncated documents
+	_, err = bsonToJSON(doc[:len(doc)-3])
+	a.Error(err)
+	// Unknown types
+	_, err = bsonToJSON(bsonDoc(bsonElem(0x42, "x", nil)))
+	a.Error(err)
+}
+
+func TestStreamBSON(t *testing.T) {
+	a := assert.New(t)
+	data := append(
+		bsonDoc(bsonElem(0x02, "_id", bsonString("a"))),
+		bsonDoc(bsonElem(0x02, "_id", bsonString("b")))...,
+	)
+	var ids []string
+	err := streamBSON(bytes.NewReader(data), func(doc gjson.Result) error {
+		ids = append(ids, doc.Get("_id").Str)
+		return nil
+	})
+	a.NoError(err)
+	a.Equal([]string{"a", "b"}, ids)
+
+	err = streamBSON(bytes.NewReader(data[:10]), func(gjson.Result) error { return nil })
+	a.Error(err)
+}
+
+func TestDecimal128(t *testing.T) {
+	a := assert.New(t)
+	// Coefficient 12345, exponent -2
+	dec := func(coef uint64, exp int, neg bool) []byte {
+		hi := uint64(exp+6176) << 49
+		if neg {
+			hi |= 1 << 63
+		}
+		b := make([]byte, 16)
+		binary.LittleEndian.PutUint64(b, coef)
+		binary.LittleEndian.PutUint64(b[8:], hi)
+		return b
+	}
+	a.Equal("123.45", decimal128(dec(12345, -2, false)))
+	a.Equal("-0.0012345", decimal128(dec(12345, -7, true)))
+	a.Equal("12345", decimal128(dec(12345, 0, false)))
+	a.Equal("1.2345E+5", decimal128(dec(12345, 1, false)))
+	a.Equal("1.2345E-8", decimal128(dec(12345, -12, false)))
+	a.Equal("0", decimal128(dec(0, 0, false)))
+}
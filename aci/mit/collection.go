@@ -0,0 +1,98 @@
+package mit
+
+import (
+	"sort"
+
+	"lib/logger"
+)
+
+// Collection summarizes the entries loaded for a class.
+type Collection struct {
+	// Namespace is the DB namespace the class was loaded into.
+	Namespace string
+	Class     string
+	// Entries is the number of source entries, e.g. pages, for the class.
+	Entries int
+	// Loaded is the number of top-level MOs loaded.
+	Loaded int
+	// Total is the APIC totalCount, or -1 if not reported.
+	Total int
+	// Skipped is the number of NDO documents skipped for having no ID.
+	Skipped int
+}
+
+// Short reports whether fewer MOs were loaded than the APIC reported.
+func (c Collection) Short() bool {
+	return c.Total >= 0 && c.Loaded < c.Total
+}
+
+// collect records a loaded entry in the collection stats.
+func (db *DB) collect(class string, loaded, total, skipped int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	c, ok := db.collections[db.key(class)]
+	if !ok {
+		c = &Collection{Namespace: db.ns, Class: class, Total: -1}
+		db.collections[db.key(class)] = c
+	}
+	c.Entries++
+	c.Loaded += loaded
+	c.Skipped += skipped
+	// Every page reports the same total
+	if total > c.Total {
+		c.Total = total
+	}
+}
+
+// warnShort logs collections that are missing MOs.
+func (db *DB) warnShort() {
+	for _, c := range db.Short() {
+		logger.Get().
+			Warn().
+			Str("namespace", c.Namespace).
+			Str("class", c.Class).
+			Int("loaded", c.Loaded).
+			Int("total", c.Total).
+			Msg("short collection")
+	}
+}
+
+// warnSkipped logs NDO collections with documents that have no ID.
+func (db *DB) warnSkipped() {
+	for _, c := range db.Collections() {
+		if c.Skipped == 0 {
+			continue
+		}
+		logger.Get().
+			Warn().
+			Str("namespace", c.Namespace).
+			Str("class", c.Class).
+			Int("skipped", c.Skipped).
+			Msg("documents without an ID")
+	}
+}
+
+// Collections returns the load summary for each class in the namespace,
+// sorted by class.
+func (db *DB) Collections() []Collection {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	res := []Collection{}
+	for _, c := range db.collections {
+		if c.Namespace == db.ns {
+			res = append(res, *c)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Class < res[j].Class })
+	return res
+}
+
+// Short returns the collections with fewer MOs than their totalCount.
+func (db *DB) Short() (res []Collection) {
+	for _, c := range db.Collections() {
+		if c.Short() {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package mit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagedClass(t *testing.T) {
+	a := assert.New(t)
+	for path, want := range map[string]string{
+		"faultInst-page0.json":     "faultInst",
+		"faultInst_page12.json.gz": "faultInst",
+		"faultInst.page-1.json":    "faultInst",
+		"pageSize.json":            "pageSize",
+	} {
+		class, ok := DefaultClass(path)
+		a.True(ok, path)
+		a.Equal(want, class, path)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	a := assert.New(t)
+	db, err := New(NewFolderSource(filepath.Join("testdata", "paged")))
+	a.NoError(err)
+	defer db.Close()
+
+	// Pages merge under the real class
+	res, err := db.Find("faultInst:*")
+	a.NoError(err)
+	a.Len(res, 4)
+
+	a.Equal([]Collection{
+		{Class: "faultInst", Entries: 2, Loaded: 4, Total: 5},
+		{Class: "fvTenant", Entries: 1, Loaded: 1, Total: 1},
+	}, db.Collections())
+
+	short := db.Short()
+	a.Len(short, 1)
+	a.Equal("faultInst", short[0].Class)
+
+	// No totalCount
+	db, err = New(NewFolderSource(filepath.Join("testdata", "children")))
+	a.NoError(err)
+	defer db.Close()
+	a.Empty(db.Short())
+}
@@ -0,0 +1,98 @@
+package mit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipBytes(t *testing.T, body []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, body []byte) []byte {
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	a := assert.New(t)
+	body := []byte(`{"imdata":[]}`)
+	for name, data := range map[string][]byte{
+		"plain": body,
+		"gzip":  gzipBytes(t, body),
+		"zstd":  zstdBytes(t, body),
+	} {
+		r, err := decompress(bytes.NewReader(data))
+		a.NoError(err, name)
+		res, err := io.ReadAll(r)
+		a.NoError(err, name)
+		a.Equal(body, res, name)
+		a.NoError(r.Close(), name)
+	}
+}
+
+func TestNewCompressed(t *testing.T) {
+	a := assert.New(t)
+
+	// Compressed members from any source
+	src := NewMemSource()
+	src.Add("fvTenant", gzipBytes(t, []byte(`{"imdata":[{"fvTenant":{"attributes":{"dn":"uni/tn-a","name":"a"}}}]}`)))
+	src.Add("fvBD", zstdBytes(t, []byte(`{"imdata":[{"fvBD":{"attributes":{"dn":"uni/tn-a/BD-b","name":"b"}}}]}`)))
+	db, err := New(src)
+	a.NoError(err)
+	res, err := db.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("a", res.Get("name").Str)
+	res, err = db.Get("fvBD:uni/tn-a/BD-b")
+	a.NoError(err)
+	a.Equal("b", res.Get("name").Str)
+	a.NoError(db.Close())
+
+	// Compressed files on disk
+	db, err = New(NewFolderSource(filepath.Join("testdata", "compressed")))
+	a.NoError(err)
+	defer db.Close()
+	res, err = db.Get("fvTenant:uni/tn-bz2")
+	a.NoError(err)
+	a.Equal("bz2", res.Get("name").Str)
+	res, err = db.Get("fvBD:uni/tn-bz2/BD-gz")
+	a.NoError(err)
+	a.Equal("gz", res.Get("name").Str)
+}
+
+func TestNewTruncated(t *testing.T) {
+	a := assert.New(t)
+	body := gzipBytes(t, []byte(`{"totalCount":"2","imdata":[
+		{"fvTenant":{"attributes":{"dn":"uni/tn-a","name":"a"}}},
+		{"fvTenant":{"attributes":{"dn":"uni/tn-b","name":"b"}}}
+	]}`))
+	for _, n := range []int{12, len(body) / 2, len(body) - 4} {
+		dir := writeTestFiles(t, map[string][]byte{"fvTenant.json.gz": body[:n]})
+		_, err := New(NewFolderSource(dir))
+		a.Error(err, n)
+	}
+}
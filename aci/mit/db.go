@@ -2,9 +2,10 @@
 package mit
 
 import (
+	"bufio"
 	_ "embed"
 	"fmt"
-	"strings"
+	"sync"
 
 	"lib/json"
 
@@ -19,97 +20,202 @@ import (
 var rnTemplateData string
 
 // DB is a key value db for the ACI MIT
-// keys are class:dn
+// keys are class:dn, or namespace|class:dn for namespaced DBs
 // values are the full JSON record
 type DB struct {
 	db *buntdb.DB
+	// ns is the namespace, e.g. fabric or snapshot ID
+	ns string
+	// mu guards collections and indexes, which are shared by all views
+	mu *sync.RWMutex
+	// collections summarizes loaded entries by namespaced class
+	collections map[string]*Collection
+	// indexes maps namespaced index names to their JSON path
+	indexes map[string]string
+	// watchers are the mutation subscriptions
+	watchers *watchers
 	// rnTemplates map[string]gjson.Result
 }
 
 // var rnTemplates map[string]gjson.Result
 
-// NewNDO creates a new DB for NDO from a temp folder path
-func NewNDO(src Source) (db DB, err error) {
+// open creates an empty in-memory DB.
+func open() (db DB, err error) {
 	d, err := buntdb.Open(":memory:")
 	if err != nil {
 		return
 	}
 	db.db = d
+	db.mu = &sync.RWMutex{}
+	db.collections = map[string]*Collection{}
+	db.indexes = map[string]string{}
+	db.watchers = &watchers{subs: map[int]*watcher{}}
+	return db, nil
+}
+
+// NewNDO creates a new DB for NDO from a temp folder path
+func NewNDO(src Source) (db DB, err error) {
+	if db, err = open(); err != nil {
+		return
+	}
+	return db, db.LoadNDO(src)
+}
+
+// LoadNDO loads NDO collections into the DB namespace.
+//
+// Collections may be newline delimited or --jsonArray mongoexport output, or
+// mongodump BSON files. Documents without an _id are skipped and reported.
+func (db *DB) LoadNDO(src Source) error {
 	entries, err := src.Entries()
 	if err != nil {
-		return db, err
+		return err
 	}
-
 	for _, entry := range entries {
-		body, err := entry.Read()
-		if err != nil {
-			return db, err
+		if err := db.loadNDO(entry); err != nil {
+			return err
 		}
+	}
+	db.warnSkipped()
+	return nil
+}
 
-		gjson.ForEachLine(string(body), func(j gjson.Result) bool {
-			oid := j.Get("_id.$oid")
-			key := fmt.Sprintf("%s:%s", entry.Class, oid.Str)
-			if err = db.Set(key, j.Raw); err != nil {
-				return false
-			}
-			return true
-		})
+// loadNDO streams an NDO collection entry into the DB.
+func (db *DB) loadNDO(entry *Entry) error {
+	r, err := entry.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
 
-		if err != nil {
-			return db, err
+	loaded, skipped := 0, 0
+	set := func(j gjson.Result) error {
+		id, ok := DocumentID(j)
+		if !ok {
+			skipped++
+			return nil
 		}
+		loaded++
+		return db.Set(fmt.Sprintf("%s:%s", entry.Class, id), j.Raw)
+	}
+
+	br := bufio.NewReader(r)
+	if isBSON(br) {
+		err = streamBSON(br, set)
+	} else {
+		err = streamDocuments(br, set)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot load %s: %v", entry.Class, err)
+	}
+	db.collect(entry.Class, loaded, -1, skipped)
+	return nil
+}
+
+// DocumentID returns the ID an NDO document is keyed by, or false if it has
+// none. IDs may be an ObjectId, a string, a UUID, binary, or a number in
+// canonical or relaxed form.
+func DocumentID(doc gjson.Result) (string, bool) {
+	id := doc.Get("_id")
+	switch id.Type {
+	case gjson.String:
+		return id.Str, id.Str != ""
+	case gjson.Number:
+		return id.Raw, true
+	case gjson.JSON:
+		for _, key := range []string{"$oid", "$numberLong", "$numberInt", "$uuid"} {
+			if v := id.Get(key); v.Exists() {
+				return v.String(), v.String() != ""
+			}
+		}
+		if b := id.Get("$binary.base64"); b.Exists() {
+			return b.Str, true
+		}
+		// Compound IDs
+		return id.Raw, id.IsObject()
+	default:
+		return "", false
 	}
-	return db, nil
 }
 
 // New creates a new DB from a temp folder path.
 func New(src Source) (db DB, err error) {
-	d, err := buntdb.Open(":memory:")
-	if err != nil {
+	if db, err = open(); err != nil {
 		return
 	}
-	db.db = d
-
-	entries, _ := src.Entries()
+	return db, db.Load(src)
+}
 
+// Load loads APIC collections into the DB namespace, e.g.
+//
+//	db.Namespace("fabric2").Load(NewFolderSource("fabric2"))
+func (db *DB) Load(src Source) error {
+	entries, err := src.Entries()
+	if err != nil {
+		return err
+	}
 	for _, entry := range entries {
-		body, err := entry.Read()
-		if err != nil {
-			return db, err
+		if err := db.load(entry); err != nil {
+			return err
 		}
-		j := gjson.ParseBytes(body)
-
-		switch imdata := j.Get("imdata"); {
-		case imdata.Get("0.moCount").Exists():
-			record := imdata.Get("0.moCount.attributes")
-			key := fmt.Sprintf("%s:%s", entry.Class, record.Get("dn").Str)
-			if err := db.Set(key, record.Raw); err != nil {
-				return db, err
+	}
+	db.warnShort()
+	return nil
+}
+
+// load streams an APIC collection entry into the DB.
+func (db *DB) load(entry *Entry) error {
+	r, err := entry.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	loaded, tree := 0, 0
+	store := db.storeTree(entry)
+	total, err := streamImdata(r, func(mo gjson.Result) error {
+		loaded++
+		if mo.Get("moCount").Exists() {
+			dn, body := entryAttributes(entry, mo.Get("moCount.attributes"))
+			return db.Set(fmt.Sprintf("%s:%s", entry.Class, dn), body)
+		}
+		for class, record := range mo.Map() {
+			dn, body := entryAttributes(entry, record.Get("attributes"))
+			if err := db.Set(class+":"+dn, body); err != nil {
+				return err
 			}
-		case imdata.Exists() && imdata.IsArray():
-			for _, mo := range imdata.Array() {
-				for class, record := range mo.Map() {
-					attrs := record.Get("attributes")
-					dn := attrs.Get("dn").Str
-					if err := db.Set(class+":"+dn, attrs.Raw); err != nil {
-						return db, err
-					}
-					children := record.Get("children")
-					if children.Exists() && children.IsArray() {
-						if err := db.setMeta(mo); err != nil {
-							return db, err
-						}
-					}
+			children := record.Get("children")
+			if children.Exists() && children.IsArray() {
+				if err := db.setMeta(mo, entry); err != nil {
+					return err
 				}
 			}
-		default:
-			// Fall back to building DNs recursively - this is *much* slower
-			if err := db.setMeta(j); err != nil {
-				return db, err
-			}
 		}
+		return nil
+	}, func(class string, attrs gjson.Result, parentDn []string) ([]string, bool, error) {
+		// Backups are a tree of MOs rather than a class collection
+		dn, ok, err := store(class, attrs, parentDn)
+		if ok {
+			tree++
+		}
+		return dn, ok, err
+	})
+	if err != nil {
+		return err
 	}
-	return db, nil
+	if tree == 0 {
+		db.collect(entry.Class, loaded, total, 0)
+	}
+	return nil
+}
+
+// entryAttributes returns the fabric DN and attributes of an MO in an entry,
+// with the DN rewritten if it is relative to the entry's node.
+func entryAttributes(entry *Entry, attrs gjson.Result) (dn, body string) {
+	dn, body = attrs.Get("dn").Str, attrs.Raw
+	if fabricDN := entry.fabricDN(dn); fabricDN != dn {
+		return fabricDN, json.Set(body, "dn", fabricDN)
+	}
+	return dn, body
 }
 
 // Close closes the DB.
@@ -117,86 +223,74 @@ func (db *DB) Close() error {
 	return db.db.Close()
 }
 
+// View runs fn in a read-only transaction with a consistent view of the DB.
+func (db *DB) View(fn func(tx *Tx) error) error {
+	return db.db.View(func(btx *buntdb.Tx) error {
+		return fn(&Tx{db: db, tx: btx, c: &changes{}})
+	})
+}
+
+// Update runs fn in a read-write transaction. Changes are committed if fn
+// returns nil and rolled back otherwise. Watchers are notified after commit.
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	return db.update(func(btx *buntdb.Tx, c *changes) error {
+		return fn(&Tx{db: db, tx: btx, c: c})
+	})
+}
+
 // Get return a value or an error
 func (db *DB) Get(key string, a ...interface{}) (res gjson.Result, err error) {
-	key = fmt.Sprintf(key, a...)
-	if err := db.db.View(func(tx *buntdb.Tx) error {
-		val, err := tx.Get(key)
-		if err != nil {
-			return err
-		}
-		res = gjson.Parse(val)
-		return nil
-	}); err != nil {
-		return res, fmt.Errorf("DB:GET:%s:%s", key, err)
-	}
-	return res, nil
+	err = db.View(func(tx *Tx) error {
+		res, err = tx.Get(key, a...)
+		return err
+	})
+	return res, err
 }
 
 // Set sets a value by key
 func (db *DB) Set(key, value string) error {
-	return db.db.Update(func(tx *buntdb.Tx) error {
-		if _, _, err := tx.Set(key, value, nil); err != nil {
-			return fmt.Errorf("cannot set key: %v", err)
-		}
-		return nil
+	return db.Update(func(tx *Tx) error {
+		return tx.Set(key, value)
 	})
 }
 
 // SetMany sets multiple values.
 func (db *DB) SetMany(vals map[string]interface{}) error {
-	return db.db.Update(func(tx *buntdb.Tx) error {
-		for k, v := range vals {
-			res := json.Marshal(v)
-			if _, _, err := tx.Set(k, res, nil); err != nil {
-				return fmt.Errorf("cannot set key: %v", err)
-			}
-		}
-		return nil
+	return db.Update(func(tx *Tx) error {
+		return tx.SetMany(vals)
 	})
 }
 
 // SetRaw ingests raw JSON
 func (db *DB) SetRaw(val string) error {
-	return db.db.Update(func(tx *buntdb.Tx) error {
-		for k, v := range gjson.Parse(val).Map() {
-			if _, _, err := tx.Set(k, v.Raw, nil); err != nil {
-				return fmt.Errorf("cannot set key: %v", err)
-			}
-		}
-		return nil
+	return db.Update(func(tx *Tx) error {
+		return tx.SetRaw(val)
 	})
 }
 
 // Find searches for values by pattern.
 func (db *DB) Find(pattern string, a ...interface{}) (res []gjson.Result, err error) {
-	pattern = fmt.Sprintf(pattern, a...)
-	pattern = strings.Replace(pattern, "//", "/", -1)
-	if err := db.db.View(func(tx *buntdb.Tx) error {
-		return tx.AscendKeys(pattern, func(_, v string) bool {
-			res = append(res, gjson.Parse(v))
-			return true
-		})
-	}); err != nil {
-		return res, fmt.Errorf("DB:FIND:%s:%s", pattern, err)
-	}
-	if strings.HasSuffix(pattern, ":*") && len(res) == 0 {
-		return res, fmt.Errorf("DB:FIND:%s:%s", pattern, "result is empty")
-	}
-	return res, nil
+	err = db.View(func(tx *Tx) error {
+		res, err = tx.Find(pattern, a...)
+		return err
+	})
+	return res, err
+}
+
+// Keys returns the keys matching a pattern.
+func (db *DB) Keys(pattern string, a ...interface{}) (res []string, err error) {
+	err = db.View(func(tx *Tx) error {
+		res, err = tx.Keys(pattern, a...)
+		return err
+	})
+	return res, err
 }
 
 // FindOne searches for a value by pattern.
 func (db *DB) FindOne(pattern string, a ...interface{}) (res gjson.Result, err error) {
-	pattern = fmt.Sprintf(pattern, a...)
-	pattern = strings.Replace(pattern, "//", "/", -1)
-	if err := db.db.View(func(tx *buntdb.Tx) error {
-		return tx.AscendKeys(pattern, func(_, v string) bool {
-			res = gjson.Parse(v)
-			return false
-		})
-	}); err != nil {
-		return res, fmt.Errorf("DB:FIND_ONE:%s:%s", pattern, err)
-	}
-	return res, nil
+	err = db.View(func(tx *Tx) error {
+		res, err = tx.FindOne(pattern, a...)
+		return err
+	})
+	return res, err
 }
@@ -9,8 +9,11 @@ import (
 )
 
 func newTestDB() DB {
-	db, _ := buntdb.Open(":memory:")
-	err := db.Update(func(tx *buntdb.Tx) error {
+	mit, err := open()
+	if err != nil {
+		panic(err)
+	}
+	err = mit.db.Update(func(tx *buntdb.Tx) error {
 		_, _, err := tx.Set("fvTenant:uni/tn-a", goaci.Body{}.Set("name", "a").Str, nil)
 		if err != nil {
 			return err
@@ -24,7 +27,7 @@ func newTestDB() DB {
 	if err != nil {
 		panic(err)
 	}
-	return DB{db: db}
+	return mit
 }
 
 func TestNewFolder(t *testing.T) {
@@ -73,7 +76,7 @@ func TestDBGet(t *testing.T) {
 
 	// key not found
 	_, err = mit.Get("fvTenant:%s", "uni/tn-c")
-	a.Error(err)
+	a.ErrorIs(err, ErrNotFound)
 }
 
 func TestDBFind(t *testing.T) {
@@ -83,6 +86,9 @@ func TestDBFind(t *testing.T) {
 	res, err := mit.Find("%s:*", "fvTenant")
 	a.NoError(err)
 	a.Equal(2, len(res))
+	res, err = mit.Find("fvTenant:uni/tn-c*")
+	a.ErrorIs(err, ErrEmpty)
+	a.Empty(res)
 }
 
 func TestDBFindOne(t *testing.T) {
@@ -93,6 +99,6 @@ func TestDBFindOne(t *testing.T) {
 	a.NoError(err)
 	a.Equal("a", res.Get("name").Str)
 	res, err = mit.FindOne("fvTenant:uni/tn-c")
-	a.NoError(err)
+	a.ErrorIs(err, ErrNotFound)
 	a.False(res.IsObject())
 }
@@ -0,0 +1,104 @@
+package mit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/buntdb"
+)
+
+// Delete deletes a value by key.
+func (db *DB) Delete(key string, a ...interface{}) error {
+	return db.Update(func(tx *Tx) error {
+		return tx.Delete(key, a...)
+	})
+}
+
+// DeletePattern deletes all values matching a pattern and returns the count.
+func (db *DB) DeletePattern(pattern string, a ...interface{}) (n int, err error) {
+	err = db.Update(func(tx *Tx) error {
+		n, err = tx.DeletePattern(pattern, a...)
+		return err
+	})
+	return n, err
+}
+
+// DeleteSubtree deletes the MOs at a DN and every MO below it, of any class,
+// and returns the count, e.g. to remove a tenant and everything in it.
+func (db *DB) DeleteSubtree(dn string) (n int, err error) {
+	err = db.Update(func(tx *Tx) error {
+		n, err = tx.DeleteSubtree(dn)
+		return err
+	})
+	return n, err
+}
+
+// Delete deletes a value by key.
+func (tx *Tx) Delete(key string, a ...interface{}) error {
+	key = fmt.Sprintf(key, a...)
+	if err := tx.c.delete(tx.tx, tx.db.key(key)); err != nil {
+		return queryError("DELETE", key, err)
+	}
+	return nil
+}
+
+// DeletePattern deletes all values matching a pattern and returns the count.
+func (tx *Tx) DeletePattern(pattern string, a ...interface{}) (n int, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	var keys []string
+	if err := tx.db.ascend(tx.tx, pattern, func(k, _ string) bool {
+		keys = append(keys, tx.db.key(k))
+		return true
+	}); err != nil {
+		return 0, queryError("DELETE_PATTERN", pattern, err)
+	}
+	n, err = tx.db.deleteKeys(tx.tx, tx.c, keys)
+	if err != nil {
+		return n, queryError("DELETE_PATTERN", pattern, err)
+	}
+	return n, nil
+}
+
+// DeleteSubtree deletes the MOs at a DN and every MO below it, of any class,
+// and returns the count.
+func (tx *Tx) DeleteSubtree(dn string) (n int, err error) {
+	keys, err := tx.db.subtreeKeys(tx.tx, dn, true)
+	if err == nil {
+		n, err = tx.db.deleteKeys(tx.tx, tx.c, keys)
+	}
+	if err != nil {
+		return n, queryError("DELETE_SUBTREE", dn, err)
+	}
+	return n, nil
+}
+
+// subtreeKeys returns the full keys of MOs below a DN in the namespace,
+// optionally including the MOs at the DN itself.
+func (db *DB) subtreeKeys(tx *buntdb.Tx, dn string, self bool) (keys []string, err error) {
+	dn = strings.TrimSuffix(dn, "/")
+	prefix := dn + "/"
+	pattern := "*:" + prefix + "*"
+	if self {
+		pattern = "*:" + dn + "*"
+	}
+	err = db.ascend(tx, pattern, func(k, _ string) bool {
+		_, moDn, ok := strings.Cut(k, ":")
+		if ok && (strings.HasPrefix(moDn, prefix) || self && moDn == dn) {
+			keys = append(keys, db.key(k))
+		}
+		return true
+	})
+	return keys, err
+}
+
+// deleteKeys deletes full keys collected before deleting,
+// since keys cannot be deleted while iterating.
+func (db *DB) deleteKeys(tx *buntdb.Tx, c *changes, keys []string) (int, error) {
+	for i, k := range keys {
+		if err := c.delete(tx, k); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,67 @@
+package mit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBDelete(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	var deleted []string
+	mit.Watch("*", func(e Event) {
+		if e.Op == OpDelete {
+			deleted = append(deleted, e.Key)
+		}
+	})
+	a.NoError(mit.Delete("fvTenant:%s", "uni/tn-a"))
+	_, err := mit.Get("fvTenant:uni/tn-a")
+	a.Error(err)
+	a.Error(mit.Delete("fvTenant:uni/tn-a"))
+	a.Equal([]string{"fvTenant:uni/tn-a"}, deleted)
+}
+
+func TestDBDeletePattern(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-1", `{}`))
+
+	n, err := mit.DeletePattern("fvTenant:*")
+	a.NoError(err)
+	a.Equal(2, n)
+	res, err := mit.Find("*")
+	a.NoError(err)
+	a.Len(res, 1)
+
+	n, err = mit.DeletePattern("fvTenant:*")
+	a.NoError(err)
+	a.Equal(0, n)
+}
+
+func TestDBDeleteSubtree(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+	a.NoError(mit.SetRaw(`{
+		"fvTenant:uni/tn-ab": {"name": "ab"},
+		"fvBD:uni/tn-a/BD-1": {"name": "1"},
+		"fvSubnet:uni/tn-a/BD-1/subnet-[10.0.0.1/24]": {"ip": "10.0.0.1/24"},
+		"fvAEPg:uni/tn-a/ap-x/epg-y": {"name": "y"},
+		"fvBD:uni/tn-b/BD-1": {"name": "1"}
+	}`))
+	// Namespaced copies are not touched
+	a.NoError(mit.Namespace("fab2").Set("fvBD:uni/tn-a/BD-1", `{}`))
+
+	n, err := mit.DeleteSubtree("uni/tn-a")
+	a.NoError(err)
+	a.Equal(4, n)
+	keys, err := mit.Keys("*")
+	a.NoError(err)
+	a.Equal([]string{"fvBD:uni/tn-b/BD-1", "fvTenant:uni/tn-ab", "fvTenant:uni/tn-b"}, keys)
+	_, err = mit.Namespace("fab2").Get("fvBD:uni/tn-a/BD-1")
+	a.NoError(err)
+}
@@ -0,0 +1,100 @@
+package mit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nodeDN matches the pod and node of a fabric DN, e.g. topology/pod-1/node-101/sys
+var nodeDN = regexp.MustCompile(`^topology/pod-(\d+)/(?:node|paths)-(\d+)(?:/|$)`)
+
+// SplitDN splits a DN into RNs, ignoring slashes in brackets, e.g.
+//
+//	SplitDN("topology/pod-1/node-101/sys/phys-[eth1/1]")
+//	// ["topology" "pod-1" "node-101" "sys" "phys-[eth1/1]"]
+func SplitDN(dn string) (rns []string) {
+	depth, start := 0, 0
+	for i, c := range dn {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				rns = append(rns, dn[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(dn) {
+		rns = append(rns, dn[start:])
+	}
+	return rns
+}
+
+// ParentDN returns the DN of the parent MO, or an empty string for a top-level MO.
+func ParentDN(dn string) string {
+	rns := SplitDN(dn)
+	if len(rns) < 2 {
+		return ""
+	}
+	return strings.Join(rns[:len(rns)-1], "/")
+}
+
+// LastRN returns the RN of an MO, e.g. phys-[eth1/1] for
+// topology/pod-1/node-101/sys/phys-[eth1/1].
+func LastRN(dn string) string {
+	rns := SplitDN(dn)
+	if len(rns) == 0 {
+		return ""
+	}
+	return rns[len(rns)-1]
+}
+
+// NodeDN returns the pod and node ID of a fabric node DN,
+// e.g. "1" and "101" for topology/pod-1/node-101/sys/phys-[eth1/1].
+func NodeDN(dn string) (pod, node string, ok bool) {
+	m := nodeDN.FindStringSubmatch(dn)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// TenantDN returns the tenant name of a DN in uni/tn-<name>.
+func TenantDN(dn string) (string, bool) {
+	rns := SplitDN(dn)
+	if len(rns) < 2 || rns[0] != "uni" || !strings.HasPrefix(rns[1], "tn-") {
+		return "", false
+	}
+	return strings.TrimPrefix(rns[1], "tn-"), true
+}
+
+// VLANID returns the VLAN ID of an encap, e.g. 100 for vlan-100.
+func VLANID(encap string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(encap, "vlan-"))
+}
+
+// LessNumeric orders strings by their embedded numbers, e.g. node 99 before
+// 101 or eth1/2 before eth1/10.
+func LessNumeric(a, b string) bool {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	}
+	na, nb := split(a), split(b)
+	for i := 0; i < len(na) && i < len(nb); i++ {
+		x, _ := strconv.Atoi(na[i])
+		y, _ := strconv.Atoi(nb[i])
+		if x != y {
+			return x < y
+		}
+	}
+	if len(na) != len(nb) {
+		return len(na) < len(nb)
+	}
+	return a < b
+}
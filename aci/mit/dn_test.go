@@ -0,0 +1,68 @@
+package mit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitDN(t *testing.T) {
+	a := assert.New(t)
+	a.Equal([]string{"topology", "pod-1", "node-101", "sys", "phys-[eth1/1]"},
+		SplitDN("topology/pod-1/node-101/sys/phys-[eth1/1]"))
+	a.Equal([]string{"uni", "tn-a", "BD-b", "subnet-[10.0.0.1/24]"},
+		SplitDN("uni/tn-a/BD-b/subnet-[10.0.0.1/24]"))
+	a.Equal([]string{"uni"}, SplitDN("uni"))
+	a.Empty(SplitDN(""))
+}
+
+func TestParentDN(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("topology/pod-1/node-101/sys/phys-[eth1/1]",
+		ParentDN("topology/pod-1/node-101/sys/phys-[eth1/1]/phys"))
+	a.Equal("uni/tn-a", ParentDN("uni/tn-a/BD-b"))
+	a.Equal("", ParentDN("uni"))
+}
+
+func TestLastRN(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("phys-[eth1/1]", LastRN("topology/pod-1/node-101/sys/phys-[eth1/1]"))
+	a.Equal("uni", LastRN("uni"))
+	a.Equal("", LastRN(""))
+}
+
+func TestNodeDN(t *testing.T) {
+	a := assert.New(t)
+	pod, node, ok := NodeDN("topology/pod-2/node-101/sys/phys-[eth1/1]")
+	a.True(ok)
+	a.Equal("2", pod)
+	a.Equal("101", node)
+	_, _, ok = NodeDN("uni/tn-a")
+	a.False(ok)
+
+	tenant, ok := TenantDN("uni/tn-a/ap-b/epg-c")
+	a.True(ok)
+	a.Equal("a", tenant)
+	_, ok = TenantDN("uni/infra")
+	a.False(ok)
+}
+
+func TestVLANID(t *testing.T) {
+	a := assert.New(t)
+	id, err := VLANID("vlan-100")
+	a.NoError(err)
+	a.Equal(100, id)
+	id, err = VLANID("200")
+	a.NoError(err)
+	a.Equal(200, id)
+	_, err = VLANID("vxlan-16777209")
+	a.Error(err)
+}
+
+func TestLessNumeric(t *testing.T) {
+	a := assert.New(t)
+	a.True(LessNumeric("eth1/2", "eth1/10"))
+	a.True(LessNumeric("99", "101"))
+	a.False(LessNumeric("eth1/10", "eth1/2"))
+	a.False(LessNumeric("101", "101"))
+}
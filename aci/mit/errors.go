@@ -0,0 +1,49 @@
+package mit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tidwall/buntdb"
+)
+
+var (
+	// ErrNotFound is returned by Get, FindOne and Delete when no key matches.
+	ErrNotFound = errors.New("not found")
+	// ErrEmpty is returned by Find when no keys match the pattern.
+	ErrEmpty = errors.New("result is empty")
+)
+
+// QueryError is a DB error with the key or pattern of the query, e.g.
+//
+//	var qerr *mit.QueryError
+//	if errors.As(err, &qerr) {
+//		log.Println(qerr.Key)
+//	}
+//
+// Use errors.Is to test for ErrNotFound and ErrEmpty.
+type QueryError struct {
+	// Op is the DB operation, e.g. GET or FIND.
+	Op string
+	// Key is the key or pattern, or the index name for index operations.
+	Key string
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("DB:%s:%s:%s", e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// queryError wraps an error from a DB operation,
+// mapping buntdb.ErrNotFound to ErrNotFound.
+func queryError(op, key string, err error) error {
+	if errors.Is(err, buntdb.ErrNotFound) {
+		err = ErrNotFound
+	}
+	return &QueryError{Op: op, Key: key, Err: err}
+}
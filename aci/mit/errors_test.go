@@ -0,0 +1,32 @@
+package mit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryError(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	_, err := mit.Get("fvTenant:%s", "uni/tn-c")
+	var qerr *QueryError
+	a.True(errors.As(err, &qerr))
+	a.Equal("GET", qerr.Op)
+	a.Equal("fvTenant:uni/tn-c", qerr.Key)
+	a.Equal("DB:GET:fvTenant:uni/tn-c:not found", err.Error())
+
+	_, err = mit.Find("fvBD:*")
+	a.True(errors.As(err, &qerr))
+	a.Equal("FIND", qerr.Op)
+	a.Equal("fvBD:*", qerr.Key)
+	a.ErrorIs(err, ErrEmpty)
+	a.NotErrorIs(err, ErrNotFound)
+
+	a.ErrorIs(mit.Delete("fvTenant:uni/tn-c"), ErrNotFound)
+	_, err = mit.FindIndex("missing", "a")
+	a.ErrorIs(err, ErrNotFound)
+}
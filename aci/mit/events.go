@@ -0,0 +1,93 @@
+package mit
+
+import (
+	"errors"
+	"fmt"
+
+	"lib/json"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+)
+
+// ApplyOptions modify how subscription events are applied.
+type ApplyOptions struct {
+	// Cascade deletes the subtree of deleted MOs.
+	Cascade bool
+}
+
+// CascadeDeletes deletes every MO under the DN of a deleted MO.
+func CascadeDeletes(opts *ApplyOptions) {
+	opts.Cascade = true
+}
+
+// ApplyEvent applies an APIC subscription event, e.g. from the websocket, to
+// the DB. MOs with a created status are set, modified MOs have their changed
+// attributes merged into the existing record, and deleted MOs are removed.
+//
+//	db.ApplyEvent(msg, CascadeDeletes)
+func (db *DB) ApplyEvent(event string, mods ...func(*ApplyOptions)) error {
+	opts := ApplyOptions{}
+	for _, mod := range mods {
+		mod(&opts)
+	}
+	return db.update(func(tx *buntdb.Tx, c *changes) error {
+		for _, mo := range gjson.Get(event, "imdata").Array() {
+			var err error
+			mo.ForEach(func(class, record gjson.Result) bool {
+				err = db.applyMO(tx, c, class.Str, record.Get("attributes"), opts)
+				return err == nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyMO applies the change for a single MO in an event.
+func (db *DB) applyMO(tx *buntdb.Tx, c *changes, class string, attrs gjson.Result, opts ApplyOptions) error {
+	dn := attrs.Get("dn").Str
+	if dn == "" {
+		return queryError("APPLY_EVENT", class, errors.New("missing dn"))
+	}
+	key := db.key(class + ":" + dn)
+
+	switch status := attrs.Get("status").Str; status {
+	case "created", "modified":
+		// Created MOs replace any existing record; modified MOs update it
+		record := `{}`
+		if status == "modified" {
+			var err error
+			record, err = tx.Get(key)
+			if err == buntdb.ErrNotFound {
+				record = `{}`
+			} else if err != nil {
+				return queryError("APPLY_EVENT", key, err)
+			}
+		}
+		attrs.ForEach(func(k, v gjson.Result) bool {
+			if k.Str != "status" {
+				record = json.SetRaw(record, k.Str, v.Raw)
+			}
+			return true
+		})
+		return c.set(tx, key, record)
+	case "deleted":
+		if err := c.delete(tx, key); err != nil && err != buntdb.ErrNotFound {
+			return err
+		}
+		if opts.Cascade {
+			keys, err := db.subtreeKeys(tx, dn, false)
+			if err != nil {
+				return err
+			}
+			_, err = db.deleteKeys(tx, c, keys)
+			return err
+		}
+		return nil
+	default:
+		return queryError("APPLY_EVENT", key, fmt.Errorf("unknown status %q", status))
+	}
+}
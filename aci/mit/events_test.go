@@ -0,0 +1,80 @@
+package mit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readEvent(t *testing.T, name string) string {
+	b, err := os.ReadFile(filepath.Join("testdata", "events", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestApplyEvent(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	var events []Event
+	mit.Watch("*", func(e Event) { events = append(events, e) })
+
+	// Created
+	a.NoError(mit.ApplyEvent(readEvent(t, "created.json")))
+	res, err := mit.Get("fvTenant:uni/tn-c")
+	a.NoError(err)
+	a.Equal("c", res.Get("name").Str)
+	a.False(res.Get("status").Exists())
+	res, err = mit.Get("fvSubnet:uni/tn-c/BD-web/subnet-[10.0.0.1/24]")
+	a.NoError(err)
+	a.Equal("10.0.0.1/24", res.Get("ip").Str)
+	a.Len(events, 3)
+
+	// Modified attributes are merged
+	a.NoError(mit.ApplyEvent(readEvent(t, "modified.json")))
+	res, err = mit.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("a", res.Get("name").Str)
+	a.Equal("modified", res.Get("descr").Str)
+	a.False(res.Get("status").Exists())
+	a.Equal("a", events[3].Old.Get("name").Str)
+	// Modified MOs that are not in the DB are created
+	res, err = mit.Get("fvBD:uni/tn-a/BD-new")
+	a.NoError(err)
+	a.Equal("no", res.Get("unicastRoute").Str)
+
+	// Deleted without cascade leaves the subtree
+	a.NoError(mit.ApplyEvent(readEvent(t, "deleted.json")))
+	_, err = mit.Get("fvTenant:uni/tn-c")
+	a.Error(err)
+	_, err = mit.Get("fvBD:uni/tn-c/BD-web")
+	a.NoError(err)
+	a.Equal(OpDelete, events[len(events)-1].Op)
+
+	// Deleted with cascade removes the subtree
+	a.NoError(mit.ApplyEvent(readEvent(t, "created.json")))
+	a.NoError(mit.Set("fvTenant:uni/tn-cc", `{"name":"cc"}`))
+	a.NoError(mit.ApplyEvent(readEvent(t, "deleted.json"), CascadeDeletes))
+	res2, err := mit.Find("*:uni/tn-c*")
+	a.NoError(err)
+	a.Len(res2, 1)
+	a.Equal("cc", res2[0].Get("name").Str)
+
+	// Invalid events
+	var qerr *QueryError
+	err = mit.ApplyEvent(`{"imdata":[{"fvTenant":{"attributes":{"status":"created"}}}]}`)
+	if a.ErrorAs(err, &qerr) {
+		a.Equal("APPLY_EVENT", qerr.Op)
+		a.Equal("fvTenant", qerr.Key)
+	}
+	err = mit.ApplyEvent(`{"imdata":[{"fvTenant":{"attributes":{"dn":"uni/tn-x","status":"unknown"}}}]}`)
+	if a.ErrorAs(err, &qerr) {
+		a.Equal("fvTenant:uni/tn-x", qerr.Key)
+		a.EqualError(err, `DB:APPLY_EVENT:fvTenant:uni/tn-x:unknown status "unknown"`)
+	}
+}
@@ -0,0 +1,115 @@
+package mit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+)
+
+// FindOptions page and order pattern searches.
+type FindOptions struct {
+	// Offset is the number of matches to skip.
+	Offset int
+	// Limit is the maximum number of matches; 0 is unlimited.
+	Limit int
+	// Desc iterates keys in descending order.
+	Desc bool
+}
+
+// Offset skips the first n matches.
+func Offset(n int) func(*FindOptions) {
+	return func(opts *FindOptions) {
+		opts.Offset = n
+	}
+}
+
+// Limit returns at most n matches.
+func Limit(n int) func(*FindOptions) {
+	return func(opts *FindOptions) {
+		opts.Limit = n
+	}
+}
+
+// Descending iterates keys in descending order.
+func Descending(opts *FindOptions) {
+	opts.Desc = true
+}
+
+// FindEach calls fn for each value matching a pattern, without building a
+// result slice. Return false from fn to stop iterating, e.g.
+//
+//	db.FindEach("faultRecord:*", fn, Limit(100), Descending)
+//
+// fn runs in a read transaction and must not call back into db, as a
+// pending write deadlocks the nested read. Use DB.View and the Tx methods
+// to query other MOs while iterating.
+func (db *DB) FindEach(pattern string, fn func(key string, v gjson.Result) bool, mods ...func(*FindOptions)) error {
+	return db.View(func(tx *Tx) error {
+		return tx.FindEach(pattern, fn, mods...)
+	})
+}
+
+// Count returns the number of keys matching a pattern.
+func (db *DB) Count(pattern string, a ...interface{}) (n int, err error) {
+	err = db.View(func(tx *Tx) error {
+		n, err = tx.Count(pattern, a...)
+		return err
+	})
+	return n, err
+}
+
+// FindEach calls fn for each value matching a pattern, without building a
+// result slice. Return false from fn to stop iterating. fn must use tx, not
+// the DB, for other queries.
+func (tx *Tx) FindEach(pattern string, fn func(key string, v gjson.Result) bool, mods ...func(*FindOptions)) error {
+	opts := FindOptions{}
+	for _, mod := range mods {
+		mod(&opts)
+	}
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	skipped, found := 0, 0
+	if err := tx.db.iterate(tx.tx, pattern, opts.Desc, func(k, v string) bool {
+		if skipped < opts.Offset {
+			skipped++
+			return true
+		}
+		found++
+		if !fn(k, gjson.Parse(v)) {
+			return false
+		}
+		return opts.Limit <= 0 || found < opts.Limit
+	}); err != nil {
+		return queryError("FIND_EACH", pattern, err)
+	}
+	return nil
+}
+
+// Count returns the number of keys matching a pattern.
+func (tx *Tx) Count(pattern string, a ...interface{}) (n int, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	if err := tx.db.ascend(tx.tx, pattern, func(_, _ string) bool {
+		n++
+		return true
+	}); err != nil {
+		return n, queryError("COUNT", pattern, err)
+	}
+	return n, nil
+}
+
+// iterate iterates keys matching a pattern within the namespace in ascending
+// or descending order.
+func (db *DB) iterate(tx *buntdb.Tx, pattern string, desc bool, fn func(key, value string) bool) error {
+	if !desc {
+		return db.ascend(tx, pattern, fn)
+	}
+	return tx.DescendKeys(db.key(pattern), func(k, v string) bool {
+		ns, rel := splitKey(k)
+		if ns != db.ns {
+			return true
+		}
+		return fn(rel, v)
+	})
+}
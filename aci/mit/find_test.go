@@ -0,0 +1,64 @@
+package mit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func newTestFaults(t *testing.T, n int) DB {
+	mit := newTestDB()
+	t.Cleanup(func() { mit.Close() })
+	vals := map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		vals[fmt.Sprintf("faultRecord:subj/rec-%02d", i)] = map[string]int{"id": i}
+	}
+	if err := mit.SetMany(vals); err != nil {
+		t.Fatal(err)
+	}
+	return mit
+}
+
+func TestFindEach(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestFaults(t, 10)
+
+	ids := func(mods ...func(*FindOptions)) (res []int64) {
+		err := mit.FindEach("faultRecord:*", func(_ string, v gjson.Result) bool {
+			res = append(res, v.Get("id").Int())
+			return true
+		}, mods...)
+		a.NoError(err)
+		return res
+	}
+	a.Len(ids(), 10)
+	a.Equal([]int64{0, 1, 2}, ids(Limit(3)))
+	a.Equal([]int64{8, 9}, ids(Offset(8)))
+	a.Equal([]int64{4, 5}, ids(Offset(4), Limit(2)))
+	a.Equal([]int64{9, 8, 7}, ids(Limit(3), Descending))
+	a.Empty(ids(Offset(20)))
+
+	// Stop early
+	var keys []string
+	a.NoError(mit.FindEach("faultRecord:*", func(k string, _ gjson.Result) bool {
+		keys = append(keys, k)
+		return len(keys) < 2
+	}))
+	a.Equal([]string{"faultRecord:subj/rec-00", "faultRecord:subj/rec-01"}, keys)
+}
+
+func TestCount(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestFaults(t, 10)
+	n, err := mit.Count("faultRecord:*")
+	a.NoError(err)
+	a.Equal(10, n)
+	n, err = mit.Count("%s:*", "fvTenant")
+	a.NoError(err)
+	a.Equal(2, n)
+	n, err = mit.Namespace("fab2").Count("*")
+	a.NoError(err)
+	a.Equal(0, n)
+}
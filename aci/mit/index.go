@@ -0,0 +1,49 @@
+package mit
+
+import (
+	"errors"
+
+	"lib/json"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+)
+
+// CreateIndex creates a JSON index on a value path for keys matching a pattern,
+// e.g. an index on siteId for all msc_schemas:* records.
+// Creating an index that already exists is a no-op.
+func (db *DB) CreateIndex(name, pattern, path string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.indexes[db.key(name)]; ok {
+		return nil
+	}
+	err := db.db.Update(func(tx *buntdb.Tx) error {
+		return tx.CreateIndex(db.key(name), db.key(pattern), buntdb.IndexJSON(path))
+	})
+	if err != nil && !errors.Is(err, buntdb.ErrIndexExists) {
+		return queryError("CREATE_INDEX", name, err)
+	}
+	db.indexes[db.key(name)] = path
+	return nil
+}
+
+// FindIndex returns the values in an index equal to value.
+func (db *DB) FindIndex(name string, value interface{}) (res []gjson.Result, err error) {
+	db.mu.RLock()
+	path, ok := db.indexes[db.key(name)]
+	db.mu.RUnlock()
+	if !ok {
+		return nil, queryError("FIND_INDEX", name, ErrNotFound)
+	}
+	pivot := json.Set("{}", path, value)
+	if err := db.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendEqual(db.key(name), pivot, func(_, v string) bool {
+			res = append(res, gjson.Parse(v))
+			return true
+		})
+	}); err != nil {
+		return res, queryError("FIND_INDEX", name, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package mit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBKeys(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+	keys, err := mit.Keys("fvTenant:*")
+	a.NoError(err)
+	a.Equal([]string{"fvTenant:uni/tn-a", "fvTenant:uni/tn-b"}, keys)
+}
+
+func TestDBIndex(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-1", `{"name":"1","vrf":"x"}`))
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-2", `{"name":"2","vrf":"y"}`))
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-3", `{"name":"3","vrf":"x"}`))
+
+	a.NoError(mit.CreateIndex("bd-vrf", "fvBD:*", "vrf"))
+	a.NoError(mit.CreateIndex("bd-vrf", "fvBD:*", "vrf"))
+	res, err := mit.FindIndex("bd-vrf", "x")
+	a.NoError(err)
+	a.Len(res, 2)
+
+	// Index is maintained on updates
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-2", `{"name":"2","vrf":"x"}`))
+	res, err = mit.FindIndex("bd-vrf", "x")
+	a.NoError(err)
+	a.Len(res, 3)
+
+	_, err = mit.FindIndex("missing", "x")
+	a.Error(err)
+}
+
+func TestDBIndexConcurrent(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+	a.NoError(mit.Set("fvBD:uni/tn-a/BD-1", `{"name":"1","vrf":"x"}`))
+
+	// Indexes are created lazily by concurrent queries, e.g. in ndo
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("bd-vrf-%d", i%4)
+			a.NoError(mit.CreateIndex(name, "fvBD:*", "vrf"))
+			res, err := mit.FindIndex(name, "x")
+			a.NoError(err)
+			a.Len(res, 1)
+			a.NotNil(mit.Collections())
+		}(i)
+	}
+	wg.Wait()
+}
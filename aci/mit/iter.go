@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package mit
+
+import (
+	"iter"
+
+	"github.com/tidwall/gjson"
+)
+
+// All returns an iterator over the keys and values matching a pattern, e.g.
+//
+//	for key, fault := range db.All("faultInst:*", Limit(10)) {
+//	}
+//
+// The iterator holds a read transaction; do not write to the DB while ranging.
+// Errors end the iteration early.
+func (db *DB) All(pattern string, mods ...func(*FindOptions)) iter.Seq2[string, gjson.Result] {
+	return func(yield func(string, gjson.Result) bool) {
+		db.FindEach(pattern, yield, mods...)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package mit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestFaults(t, 10)
+
+	var keys []string
+	for k, v := range mit.All("faultRecord:*", Offset(1), Limit(5), Descending) {
+		keys = append(keys, k)
+		a.True(v.Get("id").Exists())
+		if len(keys) == 3 {
+			break
+		}
+	}
+	a.Equal([]string{
+		"faultRecord:subj/rec-08",
+		"faultRecord:subj/rec-07",
+		"faultRecord:subj/rec-06",
+	}, keys)
+}
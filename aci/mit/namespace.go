@@ -0,0 +1,101 @@
+package mit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/match"
+)
+
+// nsSep separates the namespace from the class:dn key.
+// Namespaces may not contain the separator or a colon.
+const nsSep = "|"
+
+// Namespace returns a view of the DB scoped to a namespace, e.g. a fabric or
+// snapshot ID. The view shares the underlying store; keys and patterns passed
+// to the view are relative to the namespace.
+//
+//	fab1 := db.Namespace("fabric1")
+//	fab1.Load(src)
+//	fab1.Find("fvTenant:*")
+func (db *DB) Namespace(ns string) *DB {
+	view := *db
+	view.ns = ns
+	return &view
+}
+
+// NS returns the namespace of the DB, or an empty string for the default namespace.
+func (db *DB) NS() string {
+	return db.ns
+}
+
+// key returns the full key for a key relative to the namespace.
+func (db *DB) key(key string) string {
+	if db.ns == "" {
+		return key
+	}
+	return db.ns + nsSep + key
+}
+
+// splitKey splits a full key into namespace and class:dn.
+func splitKey(key string) (ns, rel string) {
+	i := strings.IndexAny(key, ":"+nsSep)
+	if i < 0 || key[i:i+1] != nsSep {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
+
+// ascend iterates keys matching a pattern within the namespace.
+// The key passed to fn is relative to the namespace.
+func (db *DB) ascend(tx *buntdb.Tx, pattern string, fn func(key, value string) bool) error {
+	return tx.AscendKeys(db.key(pattern), func(k, v string) bool {
+		ns, rel := splitKey(k)
+		if ns != db.ns {
+			return true
+		}
+		return fn(rel, v)
+	})
+}
+
+// FindAll searches for values by pattern in every namespace.
+// Results are grouped by namespace, e.g. every fvTenant by fabric.
+func (db *DB) FindAll(pattern string, a ...interface{}) (res map[string][]gjson.Result, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	res = map[string][]gjson.Result{}
+	if err := db.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys("*"+pattern, func(k, v string) bool {
+			ns, rel := splitKey(k)
+			if match.Match(rel, pattern) {
+				res[ns] = append(res[ns], gjson.Parse(v))
+			}
+			return true
+		})
+	}); err != nil {
+		return res, queryError("FIND_ALL", pattern, err)
+	}
+	return res, nil
+}
+
+// Namespaces returns the sorted namespaces with at least one key.
+// The default namespace is an empty string.
+func (db *DB) Namespaces() ([]string, error) {
+	seen := map[string]bool{}
+	err := db.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys("*", func(k, _ string) bool {
+			ns, _ := splitKey(k)
+			seen[ns] = true
+			return true
+		})
+	})
+	res := make([]string, 0, len(seen))
+	for ns := range seen {
+		res = append(res, ns)
+	}
+	sort.Strings(res)
+	return res, err
+}
@@ -0,0 +1,68 @@
+package mit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitKey(t *testing.T) {
+	a := assert.New(t)
+	ns, rel := splitKey("fab1|fvTenant:uni/tn-a")
+	a.Equal("fab1", ns)
+	a.Equal("fvTenant:uni/tn-a", rel)
+	ns, rel = splitKey("fvTenant:uni/tn-a|b")
+	a.Equal("", ns)
+	a.Equal("fvTenant:uni/tn-a|b", rel)
+}
+
+func TestNamespace(t *testing.T) {
+	a := assert.New(t)
+	db := newTestDB()
+	defer db.Close()
+
+	fab1 := db.Namespace("fab1")
+	a.NoError(fab1.Load(NewFolderSource(filepath.Join("testdata", "flat"))))
+	fab2 := db.Namespace("fab2")
+	a.NoError(fab2.Set("fvTenant:uni/tn-a", `{"name":"a","fabric":"fab2"}`))
+
+	// Keys are scoped to the namespace
+	res, err := fab2.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("fab2", res.Get("fabric").Str)
+	res, err = db.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("", res.Get("fabric").Str)
+	_, err = fab2.Get("fvTenant:uni/tn-b")
+	a.Error(err)
+
+	// Patterns are scoped to the namespace
+	topSystems, err := fab1.Find("topSystem:*")
+	a.NoError(err)
+	a.Greater(len(topSystems), 0)
+	_, err = db.Find("topSystem:*")
+	a.Error(err)
+	all, err := db.Find("*")
+	a.NoError(err)
+	a.Len(all, 2)
+	one, err := fab1.FindOne("*:topology/pod-1/node-1/sys")
+	a.NoError(err)
+	a.Equal("controller", one.Get("role").Str)
+
+	// Cross-namespace queries
+	tenants, err := db.FindAll("fvTenant:%s", "uni/tn-a")
+	a.NoError(err)
+	a.Len(tenants, 2)
+	a.Len(tenants[""], 1)
+	a.Len(tenants["fab2"], 1)
+
+	namespaces, err := db.Namespaces()
+	a.NoError(err)
+	a.Equal([]string{"", "fab1", "fab2"}, namespaces)
+
+	// Collections are tracked per namespace
+	a.Empty(db.Collections())
+	a.Len(fab1.Collections(), 1)
+	a.Equal("fab1", fab1.Collections()[0].Namespace)
+}
@@ -3,6 +3,7 @@ package mit
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"lib/json"
 
@@ -50,62 +51,47 @@ func buildDN(record gjson.Result, parentDn []string, rnTemplate string) []string
 		}
 	}
 
-	return append(parentDn, rn)
+	// Copy the parent so siblings don't share a backing array
+	return append(parentDn[:len(parentDn):len(parentDn)], rn)
 }
 
+// rnTemplates maps classes to their RN template, e.g. fvTenant to tn-{name}.
+var rnTemplates = sync.OnceValue(func() map[string]gjson.Result {
+	return gjson.Parse(rnTemplateData).Map()
+})
+
 // setMeta creates all records in the db for a meta record
 // e.g. rsp-subtree=full
-func (db *DB) setMeta(root gjson.Result) error {
-	type mo struct {
-		object   gjson.Result
-		parentDn []string
-		class    string
-	}
-	// Create stack and populate root node
-	stack := []mo{{object: root}}
-
-	// Read RN template map
-	rnTemplates := gjson.Parse(rnTemplateData).Map()
-
-	for len(stack) > 0 {
-		// Pop item off stack j
-		o := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-
-		// Get class/body for the current object
-		var moBody gjson.Result
-		o.object.ForEach(func(key, value gjson.Result) bool {
-			o.class = key.String()
-			moBody = value
-			return false
-		})
+// DNs relative to the entry's node are rewritten to fabric DNs.
+func (db *DB) setMeta(root gjson.Result, entry *Entry) error {
+	return walkTree(root, nil, db.storeTree(entry))
+}
 
+// storeTree returns a treeFunc that sets MOs in the DB. MOs without a DN are
+// named from their RN template and parent DN, and skipped with their
+// children if the class has no template.
+func (db *DB) storeTree(entry *Entry) treeFunc {
+	return func(class string, attrs gjson.Result, parentDn []string) ([]string, bool, error) {
 		// If the DN exists, use what's there
-		dn := moBody.Get("attributes.dn").Str
+		dn := attrs.Get("dn").Str
 		var thisDn []string
 		if dn != "" {
 			thisDn = strings.Split(dn, "/")
 		} else {
 			// Get the RN template from the lookup table
-			rnTemplate, ok := rnTemplates[o.class]
+			rnTemplate, ok := rnTemplates()[class]
 			if !ok {
-				continue
+				return nil, false, nil
 			}
-			// Get DN of current object
-			thisDn = buildDN(moBody.Get("attributes"), o.parentDn, rnTemplate.Str)
+			thisDn = buildDN(attrs, parentDn, rnTemplate.Str)
 			dn = strings.Join(thisDn, "/")
 		}
-
-		key := fmt.Sprintf("%s:%s", o.class, dn)
-		body := json.Set(moBody.Get("attributes").Raw, "dn", dn)
-		if err := db.Set(key, body); err != nil {
-			return err
-		}
-
-		// Add any children of this MO to stack
-		for _, child := range moBody.Get("children").Array() {
-			stack = append(stack, mo{object: child, parentDn: thisDn})
+		if fabricDN := entry.fabricDN(dn); fabricDN != dn {
+			dn = fabricDN
+			thisDn = strings.Split(dn, "/")
 		}
+		key := fmt.Sprintf("%s:%s", class, dn)
+		body := json.Set(attrs.Raw, "dn", dn)
+		return thisDn, true, db.Set(key, body)
 	}
-	return nil
 }
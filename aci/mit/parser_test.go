@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
 )
 
 func TestParseNDO(t *testing.T) {
@@ -24,6 +25,40 @@ func TestParseNDO(t *testing.T) {
 	res2, err := db.Find("msc_emptyFile:*")
 	a.Error(err)
 	a.Nil(res2)
+
+	// --jsonArray export with string, canonical and missing IDs
+	res3, err := db.Find("msc_jsonArray:*")
+	a.NoError(err)
+	a.Len(res3, 3)
+	res1, err = db.Get("msc_jsonArray:5ee97b394174748e7dd7b002")
+	a.NoError(err)
+	a.Equal("string", res1.Get("name").Str)
+	res1, err = db.Get("msc_jsonArray:3")
+	a.NoError(err)
+	a.Equal("canonical", res1.Get("name").Str)
+
+	// mongodump BSON; metadata files are ignored
+	res1, err = db.Get("msc_dump:5ee97b394174748e7dd7a001")
+	a.NoError(err)
+	a.Equal("Dumped", res1.Get("displayName").Str)
+	a.Equal("2020-06-16T20:13:45.123Z", res1.Get("lastUpdated.$date").Str)
+	res1, err = db.Get("msc_dump:string-id")
+	a.NoError(err)
+	a.Equal(int64(7), res1.Get("count").Int())
+	_, err = db.Find("msc_dump.metadata:*")
+	a.Error(err)
+
+	// Documents without IDs are reported
+	skipped := map[string]int{}
+	for _, c := range db.Collections() {
+		skipped[c.Class] = c.Skipped
+	}
+	a.Equal(map[string]int{
+		"msc_anpEpgRels": 0,
+		"msc_dump":       1,
+		"msc_emptyFile":  0,
+		"msc_jsonArray":  2,
+	}, skipped)
 }
 
 func TestParseFlat(t *testing.T) {
@@ -58,3 +93,66 @@ func TestParseChildren(t *testing.T) {
 		a.True(healthInst.Exists(), "healthInst not found")
 	}
 }
+
+func TestParseBackup(t *testing.T) {
+	a := assert.New(t)
+	dir := writeTestFiles(t, map[string][]byte{"config.json": []byte(`{"polUni": {
+		"attributes": {"dn": "uni"},
+		"children": [{"fvTenant": {
+			"attributes": {"name": "a"},
+			"children": [
+				{"fvAp": {
+					"attributes": {"name": "shop"},
+					"children": [
+						{"fvAEPg": {"attributes": {"name": "web"}, "children": [{"fvRsBd": {"attributes": {"tnFvBDName": "bd1"}}}]}},
+						{"fvAEPg": {"attributes": {"name": "app"}, "children": [{"fvRsBd": {"attributes": {"tnFvBDName": "bd2"}}}]}}
+					]
+				}},
+				{"fvBD": {"attributes": {"name": "bd1"}}}
+			]
+		}}]
+	}}`)})
+	db, err := New(NewFolderSource(dir))
+	a.NoError(err)
+	defer db.Close()
+
+	for _, key := range []string{
+		"polUni:uni",
+		"fvTenant:uni/tn-a",
+		"fvBD:uni/tn-a/BD-bd1",
+		"fvAEPg:uni/tn-a/ap-shop/epg-web",
+		"fvAEPg:uni/tn-a/ap-shop/epg-app",
+	} {
+		_, err := db.Get(key)
+		a.NoError(err, key)
+	}
+	rel, err := db.Get("fvRsBd:uni/tn-a/ap-shop/epg-web/rsbd")
+	a.NoError(err)
+	a.Equal("bd1", rel.Get("tnFvBDName").Str)
+	rel, err = db.Get("fvRsBd:uni/tn-a/ap-shop/epg-app/rsbd")
+	a.NoError(err)
+	a.Equal("bd2", rel.Get("tnFvBDName").Str)
+	// Backups are not class collections
+	a.Empty(db.Collections())
+}
+
+func TestDocumentID(t *testing.T) {
+	a := assert.New(t)
+	for doc, want := range map[string]string{
+		`{"_id":{"$oid":"5ee97b394174748e7dd74eb8"}}`:              "5ee97b394174748e7dd74eb8",
+		`{"_id":"string-id"}`:                                      "string-id",
+		`{"_id":7}`:                                                "7",
+		`{"_id":{"$numberLong":"42"}}`:                             "42",
+		`{"_id":{"$numberInt":"3"}}`:                               "3",
+		`{"_id":{"$uuid":"0f6e0fb7-6b5c-4d1e-9c1a-2a8f1f3b4c5d"}}`: "0f6e0fb7-6b5c-4d1e-9c1a-2a8f1f3b4c5d",
+		`{"_id":{"$binary":{"base64":"AQID","subType":"00"}}}`:     "AQID",
+	} {
+		id, ok := DocumentID(gjson.Parse(doc))
+		a.True(ok, doc)
+		a.Equal(want, id, doc)
+	}
+	_, ok := DocumentID(gjson.Parse(`{"name":"no id"}`))
+	a.False(ok)
+	_, ok = DocumentID(gjson.Parse(`{"_id":""}`))
+	a.False(ok)
+}
@@ -1,15 +1,106 @@
 package mit
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 // Entry is an individual source entry
 type Entry struct {
 	Class string
-	Read  func() ([]byte, error)
+	// Pod and Node are the pod and node IDs for per-node collections, e.g.
+	// pod-1/node-101/topSystem.json. Pod is empty if the path has none.
+	Pod  string
+	Node string
+	// Size is a hint for the size of the entry body in bytes, or 0 if unknown.
+	Size int64
+	// Open opens a stream of the entry body.
+	Open func() (io.ReadCloser, error)
+	// Read reads the entire entry body as stored, e.g. gzip compressed.
+	//
+	// Deprecated: use Body, which decompresses the body. Sources set Read for
+	// existing callers; new sources only need Open.
+	Read func() ([]byte, error)
+}
+
+// Reader opens the entry contents for streaming.
+// Gzip, zstd and bzip2 compressed data is decompressed transparently.
+func (e *Entry) Reader() (io.ReadCloser, error) {
+	var (
+		raw io.ReadCloser
+		err error
+	)
+	switch {
+	case e.Open != nil:
+		raw, err = e.Open()
+	case e.Read != nil:
+		var b []byte
+		b, err = e.Read()
+		raw = io.NopCloser(bytes.NewReader(b))
+	default:
+		return nil, fmt.Errorf("entry %s is not readable", e.Class)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := decompress(raw)
+	if err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return readCloser{Reader: r, closers: []io.Closer{r, raw}}, nil
+}
+
+// Body returns the entry contents.
+// Gzip, zstd and bzip2 compressed data is decompressed transparently.
+func (e *Entry) Body() ([]byte, error) {
+	r, err := e.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var buf bytes.Buffer
+	if e.Size > 0 {
+		buf.Grow(int(e.Size))
+	}
+	_, err = buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
+// fabricDN returns the fabric DN for a DN in the entry. Per-node collections,
+// e.g. moquery output on a switch, have DNs relative to the node, e.g.
+// sys/phys-[eth1/1], which are rewritten to topology/pod-1/node-101/sys/...
+// The pod defaults to 1 if the entry path has none.
+func (e *Entry) fabricDN(dn string) string {
+	if e == nil || e.Node == "" || (dn != "sys" && !strings.HasPrefix(dn, "sys/")) {
+		return dn
+	}
+	pod := e.Pod
+	if pod == "" {
+		pod = "1"
+	}
+	return fmt.Sprintf("topology/pod-%s/node-%s/%s", pod, e.Node, dn)
+}
+
+// readCloser closes multiple layers of a reader stack.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes all layers, returning the first error.
+func (rc readCloser) Close() (err error) {
+	for _, c := range rc.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
 }
 
 // Source is a source for the DB data
@@ -17,6 +108,58 @@ type Source interface {
 	Entries() ([]*Entry, error)
 }
 
+// ClassFunc maps a file path, relative to the source root, to a class.
+// Return false to skip the file.
+type ClassFunc func(path string) (class string, ok bool)
+
+var (
+	// nodeDir matches per-node subfolders, e.g. node-101/ or pod-1/node-101/
+	nodeDir = regexp.MustCompile(`(?:^|/)(?:pod-(\d+)/)?node-(\d+)/`)
+	// pageSuffix matches paged collections, e.g. faultInst-page0
+	pageSuffix = regexp.MustCompile(`[-_.]page-?\d+$`)
+)
+
+// DefaultClass maps fvTenant.json to fvTenant.
+// Compressed files, e.g. fvTenant.json.gz or fvTenant.json.zst, are included.
+// Pages, e.g. faultInst-page0.json and faultInst-page1.json, map to faultInst.
+// NDO mongodump files, e.g. msc_schemas.bson, map to the collection name.
+func DefaultClass(path string) (string, bool) {
+	name := filepath.Base(path)
+	for _, ext := range compressedExts {
+		name = strings.TrimSuffix(name, ext)
+	}
+	switch {
+	case strings.HasSuffix(name, ".bson"):
+		return strings.TrimSuffix(name, ".bson"), true
+	case strings.HasSuffix(name, ".metadata.json"):
+		// mongodump collection metadata
+		return "", false
+	case !strings.HasSuffix(name, ".json"):
+		return "", false
+	}
+	name = strings.TrimSuffix(name, ".json")
+	return pageSuffix.ReplaceAllString(name, ""), true
+}
+
+// ClassPattern maps file paths to classes with a regular expression.
+// The path is slash separated and relative to the source root.
+// The class is the submatch named "class", or the first submatch.
+//
+//	ClassPattern(regexp.MustCompile(`^(\w+)_\d+\.json$`))
+func ClassPattern(re *regexp.Regexp) ClassFunc {
+	idx := re.SubexpIndex("class")
+	if idx < 0 {
+		idx = 1
+	}
+	return func(path string) (string, bool) {
+		m := re.FindStringSubmatch(path)
+		if m == nil || idx >= len(m) || m[idx] == "" {
+			return "", false
+		}
+		return m[idx], true
+	}
+}
+
 // MemSource is an in-memory source.
 //
 // Used to pass collection results directly without touching disk.
@@ -29,6 +172,21 @@ func NewMemSource() *MemSource {
 	return &MemSource{entries: []*Entry{}}
 }
 
+// Add adds an entry body for a class.
+// The body may be compressed.
+func (src *MemSource) Add(class string, body []byte) {
+	src.entries = append(src.entries, &Entry{
+		Class: class,
+		Size:  int64(len(body)),
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		},
+		Read: func() ([]byte, error) {
+			return body, nil
+		},
+	})
+}
+
 // Entries fulfills the Source interface.
 func (src *MemSource) Entries() ([]*Entry, error) {
 	return src.entries, nil
@@ -39,15 +197,35 @@ func (src *MemSource) Entries() ([]*Entry, error) {
 // Used to read collection results from a temp folder, e.g. a zip archive.
 type FolderSource struct {
 	Path string
+	// Class maps file paths to classes; defaults to DefaultClass.
+	Class ClassFunc
 }
 
 // NewFolderSource creates a new source for folder entries
-func NewFolderSource(path string) *FolderSource {
-	return &FolderSource{Path: path}
+// Pass modifiers to change the file naming rules, e.g.
+//
+//	NewFolderSource("collection", ClassMapper(ClassPattern(re)))
+func NewFolderSource(path string, mods ...func(*FolderSource)) *FolderSource {
+	src := &FolderSource{Path: path, Class: DefaultClass}
+	for _, mod := range mods {
+		mod(src)
+	}
+	return src
+}
+
+// ClassMapper sets the file path to class mapping for a folder source.
+func ClassMapper(fn ClassFunc) func(*FolderSource) {
+	return func(src *FolderSource) {
+		src.Class = fn
+	}
 }
 
 // Entries fulfills the Source interface.
 func (src *FolderSource) Entries() (entries []*Entry, err error) {
+	classFunc := src.Class
+	if classFunc == nil {
+		classFunc = DefaultClass
+	}
 	err = filepath.WalkDir(src.Path, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -55,16 +233,31 @@ func (src *FolderSource) Entries() (entries []*Entry, err error) {
 		if d.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(d.Name(), ".json") {
+		rel, err := filepath.Rel(src.Path, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		class, ok := classFunc(rel)
+		if !ok {
 			return nil
 		}
-		class := strings.TrimSuffix(d.Name(), ".json")
-		entries = append(entries, &Entry{
+		entry := &Entry{
 			Class: class,
+			Open: func() (io.ReadCloser, error) {
+				return os.Open(path)
+			},
 			Read: func() ([]byte, error) {
 				return os.ReadFile(path)
 			},
-		})
+		}
+		if info, err := d.Info(); err == nil {
+			entry.Size = info.Size()
+		}
+		if m := nodeDir.FindStringSubmatch(rel); m != nil {
+			entry.Pod, entry.Node = m[1], m[2]
+		}
+		entries = append(entries, entry)
 		return nil
 	})
 	return
@@ -0,0 +1,125 @@
+package mit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestFiles creates files relative to a temp dir.
+func writeTestFiles(t *testing.T, files map[string][]byte) string {
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, body, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDefaultClass(t *testing.T) {
+	a := assert.New(t)
+	class, ok := DefaultClass("topSystemHealth.json")
+	a.True(ok)
+	a.Equal("topSystemHealth", class)
+	class, ok = DefaultClass("node-101/fvTenant.json.gz")
+	a.True(ok)
+	a.Equal("fvTenant", class)
+	_, ok = DefaultClass("README.md")
+	a.False(ok)
+}
+
+func TestClassPattern(t *testing.T) {
+	a := assert.New(t)
+	fn := ClassPattern(regexp.MustCompile(`^(?:.*/)?\d+_(?P<class>\w+)\.json$`))
+	class, ok := fn("apic1/01_fvTenant.json")
+	a.True(ok)
+	a.Equal("fvTenant", class)
+	_, ok = fn("fvTenant.json")
+	a.False(ok)
+
+	fn = ClassPattern(regexp.MustCompile(`^(\w+)\.out$`))
+	class, ok = fn("fvBD.out")
+	a.True(ok)
+	a.Equal("fvBD", class)
+}
+
+func TestFolderSourceEntries(t *testing.T) {
+	a := assert.New(t)
+	body := []byte(`{"imdata":[]}`)
+	dir := writeTestFiles(t, map[string][]byte{
+		"fvTenant.json":                 body,
+		"notes.txt":                     body,
+		"node-101/topSystem.json":       body,
+		"pod-1/node-102/eqptCh.json.gz": gzipBytes(t, body),
+	})
+
+	entries, err := NewFolderSource(dir).Entries()
+	a.NoError(err)
+	a.Len(entries, 3)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Class < entries[j].Class })
+
+	a.Equal("eqptCh", entries[0].Class)
+	a.Equal("1", entries[0].Pod)
+	a.Equal("102", entries[0].Node)
+	res, err := entries[0].Body()
+	a.NoError(err)
+	a.Equal(body, res)
+
+	a.Equal("fvTenant", entries[1].Class)
+	a.Equal("", entries[1].Node)
+	a.Equal(int64(len(body)), entries[1].Size)
+
+	a.Equal("topSystem", entries[2].Class)
+	a.Equal("", entries[2].Pod)
+	a.Equal("101", entries[2].Node)
+
+	// Custom mapping
+	src := NewFolderSource(dir, ClassMapper(ClassPattern(regexp.MustCompile(`^(\w+)\.txt$`))))
+	entries, err = src.Entries()
+	a.NoError(err)
+	a.Len(entries, 1)
+	a.Equal("notes", entries[0].Class)
+}
+
+func TestLoadNodeRelativeDN(t *testing.T) {
+	a := assert.New(t)
+	physIf := func(speed string) []byte {
+		return []byte(`{"totalCount":"1","imdata":[{"l1PhysIf":{"attributes":{"dn":"sys/phys-[eth1/1]","speed":"` + speed + `"}}}]}`)
+	}
+	dir := writeTestFiles(t, map[string][]byte{
+		"node-101/l1PhysIf.json":       physIf("10G"),
+		"pod-2/node-201/l1PhysIf.json": physIf("100G"),
+		"node-102/topSystem.json": []byte(`{"imdata":[{"topSystem":{"attributes":{"dn":"sys","id":"102"},` +
+			`"children":[{"healthInst":{"attributes":{"cur":"100"}}}]}}]}`),
+		"fvTenant.json": []byte(`{"imdata":[{"fvTenant":{"attributes":{"dn":"uni/tn-a"}}}]}`),
+	})
+	db, err := New(NewFolderSource(dir))
+	a.NoError(err)
+	defer db.Close()
+
+	res, err := db.Find("l1PhysIf:*")
+	a.NoError(err)
+	if a.Len(res, 2) {
+		a.Equal("topology/pod-1/node-101/sys/phys-[eth1/1]", res[0].Get("dn").Str)
+		a.Equal("10G", res[0].Get("speed").Str)
+		a.Equal("topology/pod-2/node-201/sys/phys-[eth1/1]", res[1].Get("dn").Str)
+		a.Equal("100G", res[1].Get("speed").Str)
+	}
+	_, err = db.Get("topSystem:topology/pod-1/node-102/sys")
+	a.NoError(err)
+	health, err := db.Get("healthInst:topology/pod-1/node-102/sys/health")
+	a.NoError(err)
+	a.Equal("100", health.Get("cur").Str)
+	// Fabric DNs are unchanged
+	_, err = db.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+}
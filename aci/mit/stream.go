@@ -0,0 +1,304 @@
+package mit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/tidwall/gjson"
+)
+
+// treeFunc stores an MO of a tree document, e.g. a config backup, with its
+// class, attributes and parent DN. It returns the MO's DN, or false to skip
+// the MO and its children.
+type treeFunc func(class string, attrs gjson.Result, parentDn []string) (dn []string, ok bool, err error)
+
+// streamImdata streams the imdata array of an APIC response, calling fn for
+// each MO without reading the full response into memory.
+//
+// The response totalCount is returned, or -1 if there is none. Other top-level
+// fields are MO trees, e.g. {"polUni": {...}} in a config backup, which are
+// streamed one MO at a time with tree, parents before their children. Only
+// the attributes of an MO are held in memory, unless its children come before
+// its attributes, in which case its subtree is buffered. Documents that are not
+// a JSON object, e.g. BSON or NDO exports, have no MOs; read and decompression
+// errors are returned.
+func streamImdata(r io.Reader, fn func(mo gjson.Result) error, tree treeFunc) (total int, err error) {
+	total = -1
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	var syntax *json.SyntaxError
+	switch {
+	case err == io.EOF, errors.As(err, &syntax):
+		// Empty, or not JSON, e.g. BSON
+		return total, nil
+	case err != nil:
+		return total, err
+	case tok != json.Delim('{'):
+		// Not an APIC document, e.g. a JSON array
+		return total, nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return total, err
+		}
+		switch key {
+		case "totalCount":
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return total, err
+			}
+			total = int(gjson.ParseBytes(raw).Int())
+		case "imdata":
+			if err := streamArray(dec, func() error {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return err
+				}
+				return fn(gjson.ParseBytes(raw))
+			}); err != nil {
+				return total, err
+			}
+		default:
+			if err := streamMO(dec, key.(string), nil, tree); err != nil {
+				return total, err
+			}
+		}
+	}
+	if _, err = dec.Token(); err != nil {
+		return total, err
+	}
+	// Read to the end, e.g. to check a gzip trailer
+	_, err = io.Copy(io.Discard, r)
+	return total, err
+}
+
+// streamArray calls fn for each element of the array at the decoder, which
+// must consume the element. Other values are skipped.
+func streamArray(dec *json.Decoder, fn func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return skipValue(dec, tok)
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// streamMO streams the MO object at the decoder, e.g.
+// {"attributes": {...}, "children": [...]}, and its children.
+// Values other than objects are skipped.
+func streamMO(dec *json.Decoder, class string, parentDn []string, tree treeFunc) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return skipValue(dec, tok)
+	}
+	var (
+		attrs    gjson.Result
+		dn       []string
+		ok       bool
+		seen     bool
+		children json.RawMessage
+	)
+	// store stores the MO once, after its attributes or before its children
+	store := func() error {
+		if seen {
+			return nil
+		}
+		seen = true
+		dn, ok, err = tree(class, attrs, parentDn)
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch {
+		case key == "attributes" && !seen:
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			attrs = gjson.ParseBytes(raw)
+			if err := store(); err != nil {
+				return err
+			}
+		case key == "children" && !seen:
+			// Attributes come first in APIC output; buffer otherwise
+			if err := dec.Decode(&children); err != nil {
+				return err
+			}
+		case key == "children":
+			if err := streamArray(dec, func() error {
+				return streamChild(dec, dn, ok, tree)
+			}); err != nil {
+				return err
+			}
+		default:
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if err := store(); err != nil {
+		return err
+	}
+	if ok && children != nil {
+		return walkChildren(gjson.ParseBytes(children), dn, tree)
+	}
+	return nil
+}
+
+// streamChild streams a child of an MO, e.g. {"fvAp": {...}}. Children of
+// skipped MOs are read and discarded.
+func streamChild(dec *json.Decoder, parentDn []string, ok bool, tree treeFunc) error {
+	if !ok {
+		var raw json.RawMessage
+		return dec.Decode(&raw)
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return skipValue(dec, tok)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := streamMO(dec, key.(string), parentDn, tree); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// walkChildren stores the buffered children of an MO and their subtrees.
+func walkChildren(children gjson.Result, parentDn []string, tree treeFunc) error {
+	for _, child := range children.Array() {
+		if err := walkTree(child, parentDn, tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTree stores a parsed MO, e.g. {"fvAp": {...}}, and its subtree.
+func walkTree(mo gjson.Result, parentDn []string, tree treeFunc) (err error) {
+	mo.ForEach(func(class, body gjson.Result) bool {
+		var (
+			dn []string
+			ok bool
+		)
+		dn, ok, err = tree(class.Str, body.Get("attributes"), parentDn)
+		if err == nil && ok {
+			err = walkChildren(body.Get("children"), dn, tree)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// skipValue skips the rest of a value whose first token has been read.
+func skipValue(dec *json.Decoder, tok json.Token) error {
+	depth := 0
+	for {
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// firstByte returns the first non-whitespace byte without consuming it.
+func firstByte(br *bufio.Reader) byte {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		br.UnreadByte()
+		return b
+	}
+}
+
+// isBSON reports whether the data looks like BSON rather than JSON.
+// BSON starts with a little-endian document size; any four bytes of JSON text
+// decode to a size far larger than the maximum.
+func isBSON(br *bufio.Reader) bool {
+	b, err := br.Peek(4)
+	if err != nil {
+		return false
+	}
+	n := binary.LittleEndian.Uint32(b)
+	return n >= 5 && n <= maxBSONSize
+}
+
+// streamDocuments streams whitespace or newline separated JSON documents, or
+// a JSON array of documents, e.g. mongoexport output, calling fn for each
+// document.
+func streamDocuments(r io.Reader, fn func(doc gjson.Result) error) error {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	if firstByte(br) == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			if err := fn(gjson.ParseBytes(raw)); err != nil {
+				return err
+			}
+		}
+		_, err := dec.Token()
+		return err
+	}
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(gjson.ParseBytes(raw)); err != nil {
+			return err
+		}
+	}
+}
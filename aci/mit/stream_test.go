@@ -0,0 +1,126 @@
+package mit
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamImdata(t *testing.T) {
+	a := assert.New(t)
+	noTree := func(class string, _ gjson.Result, _ []string) ([]string, bool, error) {
+		return nil, false, nil
+	}
+
+	// imdata is streamed regardless of field order
+	var dns []string
+	total, err := streamImdata(strings.NewReader(`{
+		"totalCount": "2",
+		"imdata": [
+			{"fvTenant": {"attributes": {"dn": "uni/tn-a"}}},
+			{"fvTenant": {"attributes": {"dn": "uni/tn-b"}}}
+		],
+		"trailer": {"x": [1, 2]}
+	}`), func(mo gjson.Result) error {
+		dns = append(dns, mo.Get("fvTenant.attributes.dn").Str)
+		return nil
+	}, noTree)
+	a.NoError(err)
+	a.Equal(2, total)
+	a.Equal([]string{"uni/tn-a", "uni/tn-b"}, dns)
+
+	// totalCount after imdata
+	total, err = streamImdata(strings.NewReader(`{"imdata":[],"totalCount":"7"}`), nil, noTree)
+	a.NoError(err)
+	a.Equal(7, total)
+
+	// Empty document
+	total, err = streamImdata(strings.NewReader(""), nil, noTree)
+	a.NoError(err)
+	a.Equal(-1, total)
+
+	// Not an APIC document
+	_, err = streamImdata(strings.NewReader(`[{"_id": 1}]`), nil, noTree)
+	a.NoError(err)
+	_, err = streamImdata(strings.NewReader("\x16\x00\x00\x00\x02_id"), nil, noTree)
+	a.NoError(err)
+
+	// Read errors are returned
+	_, err = streamImdata(iotest.ErrReader(io.ErrUnexpectedEOF), nil, noTree)
+	a.ErrorIs(err, io.ErrUnexpectedEOF)
+	_, err = streamImdata(strings.NewReader(`{"imdata":[`), func(gjson.Result) error { return nil }, noTree)
+	a.Error(err)
+}
+
+func TestStreamTree(t *testing.T) {
+	a := assert.New(t)
+	// Documents without imdata are streamed one MO at a time, parents first
+	var mos []string
+	total, err := streamImdata(strings.NewReader(`{"polUni": {
+		"attributes": {"dn": "uni"},
+		"children": [
+			{"fvTenant": {
+				"attributes": {"name": "a"},
+				"children": [{"fvBD": {"attributes": {"name": "bd"}}}]
+			}},
+			{"fvTenant": {
+				"children": [{"fvAp": {"attributes": {"name": "ap"}}}],
+				"attributes": {"name": "b"}
+			}},
+			{"unknown": {"children": [{"fvBD": {"attributes": {"name": "x"}}}]}}
+		]
+	}}`), func(gjson.Result) error {
+		t.Fatal("unexpected imdata MO")
+		return nil
+	}, func(class string, attrs gjson.Result, parentDn []string) ([]string, bool, error) {
+		if class == "unknown" {
+			return nil, false, nil
+		}
+		dn := append(parentDn[:len(parentDn):len(parentDn)], class+"-"+attrs.Get("name").Str)
+		if attrs.Get("dn").Exists() {
+			dn = []string{attrs.Get("dn").Str}
+		}
+		mos = append(mos, strings.Join(dn, "/"))
+		return dn, true, nil
+	})
+	a.NoError(err)
+	a.Equal(-1, total)
+	a.Equal([]string{
+		"uni",
+		"uni/fvTenant-a",
+		"uni/fvTenant-a/fvBD-bd",
+		// Children before attributes are buffered until the parent is stored
+		"uni/fvTenant-b",
+		"uni/fvTenant-b/fvAp-ap",
+	}, mos)
+}
+
+func TestStreamDocuments(t *testing.T) {
+	a := assert.New(t)
+	var ids []string
+	err := streamDocuments(strings.NewReader("{\"_id\":1}\n\n{\"_id\":2} {\"_id\":3}\n"), func(doc gjson.Result) error {
+		ids = append(ids, doc.Get("_id").Raw)
+		return nil
+	})
+	a.NoError(err)
+	a.Equal([]string{"1", "2", "3"}, ids)
+}
+
+func TestEntryReader(t *testing.T) {
+	a := assert.New(t)
+	body := []byte(`{"imdata":[]}`)
+
+	// Read only entries are still supported
+	entry := &Entry{Class: "fvTenant", Read: func() ([]byte, error) { return gzipBytes(t, body), nil }}
+	res, err := entry.Body()
+	a.NoError(err)
+	a.Equal(body, res)
+
+	// Entries without a body
+	_, err = (&Entry{Class: "fvTenant"}).Reader()
+	a.Error(err)
+}
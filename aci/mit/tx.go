@@ -0,0 +1,105 @@
+package mit
+
+import (
+	"fmt"
+	"strings"
+
+	"lib/json"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+)
+
+// Tx is a DB transaction.
+// Use DB.View or DB.Update to run a transaction.
+type Tx struct {
+	db *DB
+	tx *buntdb.Tx
+	c  *changes
+}
+
+// Get return a value or an error.
+// It returns ErrNotFound if the key does not exist.
+func (tx *Tx) Get(key string, a ...interface{}) (res gjson.Result, err error) {
+	key = fmt.Sprintf(key, a...)
+	val, err := tx.tx.Get(tx.db.key(key))
+	if err != nil {
+		return res, queryError("GET", key, err)
+	}
+	return gjson.Parse(val), nil
+}
+
+// Set sets a value by key
+func (tx *Tx) Set(key, value string) error {
+	if err := tx.c.set(tx.tx, tx.db.key(key), value); err != nil {
+		return fmt.Errorf("cannot set key: %v", err)
+	}
+	return nil
+}
+
+// SetMany sets multiple values.
+func (tx *Tx) SetMany(vals map[string]interface{}) error {
+	for k, v := range vals {
+		if err := tx.Set(k, json.Marshal(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetRaw ingests raw JSON
+func (tx *Tx) SetRaw(val string) (err error) {
+	gjson.Parse(val).ForEach(func(k, v gjson.Result) bool {
+		err = tx.Set(k.Str, v.Raw)
+		return err == nil
+	})
+	return err
+}
+
+// Find searches for values by pattern.
+// It returns ErrEmpty if no keys match.
+func (tx *Tx) Find(pattern string, a ...interface{}) (res []gjson.Result, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	if err := tx.db.ascend(tx.tx, pattern, func(_, v string) bool {
+		res = append(res, gjson.Parse(v))
+		return true
+	}); err != nil {
+		return res, queryError("FIND", pattern, err)
+	}
+	if len(res) == 0 {
+		return res, queryError("FIND", pattern, ErrEmpty)
+	}
+	return res, nil
+}
+
+// Keys returns the keys matching a pattern.
+func (tx *Tx) Keys(pattern string, a ...interface{}) (res []string, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	if err := tx.db.ascend(tx.tx, pattern, func(k, _ string) bool {
+		res = append(res, k)
+		return true
+	}); err != nil {
+		return res, queryError("KEYS", pattern, err)
+	}
+	return res, nil
+}
+
+// FindOne searches for a value by pattern.
+// It returns ErrNotFound if no keys match.
+func (tx *Tx) FindOne(pattern string, a ...interface{}) (res gjson.Result, err error) {
+	pattern = fmt.Sprintf(pattern, a...)
+	pattern = strings.Replace(pattern, "//", "/", -1)
+	found := false
+	if err := tx.db.ascend(tx.tx, pattern, func(_, v string) bool {
+		res, found = gjson.Parse(v), true
+		return false
+	}); err != nil {
+		return res, queryError("FIND_ONE", pattern, err)
+	}
+	if !found {
+		return res, queryError("FIND_ONE", pattern, ErrNotFound)
+	}
+	return res, nil
+}
@@ -0,0 +1,95 @@
+package mit
+
+import (
+	"errors"
+	"testing"
+
+	"lib/json"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBView(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	err := mit.View(func(tx *Tx) error {
+		res, err := tx.Get("fvTenant:%s", "uni/tn-a")
+		a.NoError(err)
+		a.Equal("a", res.Get("name").Str)
+		all, err := tx.Find("fvTenant:*")
+		a.NoError(err)
+		a.Len(all, 2)
+		one, err := tx.FindOne("fvTenant:*-b")
+		a.NoError(err)
+		a.Equal("b", one.Get("name").Str)
+		keys, err := tx.Keys("fvTenant:*")
+		a.NoError(err)
+		a.Len(keys, 2)
+
+		// Read-only transactions cannot write
+		a.Error(tx.Set("fvTenant:uni/tn-c", `{}`))
+		a.Error(tx.Delete("fvTenant:uni/tn-a"))
+		return nil
+	})
+	a.NoError(err)
+}
+
+func TestDBUpdate(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	var events []Event
+	mit.Watch("*", func(e Event) { events = append(events, e) })
+
+	// Read-modify-write
+	err := mit.Update(func(tx *Tx) error {
+		res, err := tx.Get("fvTenant:uni/tn-a")
+		if err != nil {
+			return err
+		}
+		if err := tx.Set("fvTenant:uni/tn-a", json.Set(res.Raw, "descr", "x")); err != nil {
+			return err
+		}
+		if err := tx.SetMany(map[string]interface{}{"fvBD:uni/tn-a/BD-1": map[string]string{"name": "1"}}); err != nil {
+			return err
+		}
+		if err := tx.SetRaw(`{"fvBD:uni/tn-a/BD-2":{"name":"2"}}`); err != nil {
+			return err
+		}
+		_, err = tx.DeletePattern("fvTenant:uni/tn-b")
+		return err
+	})
+	a.NoError(err)
+	res, err := mit.Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("x", res.Get("descr").Str)
+	keys, err := mit.Keys("*")
+	a.NoError(err)
+	a.Equal([]string{"fvBD:uni/tn-a/BD-1", "fvBD:uni/tn-a/BD-2", "fvTenant:uni/tn-a"}, keys)
+	a.Len(events, 4)
+
+	// Errors roll back all changes without notifying watchers
+	errStop := errors.New("stop")
+	err = mit.Update(func(tx *Tx) error {
+		if _, err := tx.DeleteSubtree("uni/tn-a"); err != nil {
+			return err
+		}
+		return errStop
+	})
+	a.ErrorIs(err, errStop)
+	keys, err = mit.Keys("*")
+	a.NoError(err)
+	a.Len(keys, 3)
+	a.Len(events, 4)
+
+	// Transactions are scoped to the namespace
+	a.NoError(mit.Namespace("fab2").Update(func(tx *Tx) error {
+		return tx.Set("fvTenant:uni/tn-a", `{"name":"fab2"}`)
+	}))
+	res, err = mit.Namespace("fab2").Get("fvTenant:uni/tn-a")
+	a.NoError(err)
+	a.Equal("fab2", res.Get("name").Str)
+}
@@ -0,0 +1,28 @@
+package mit
+
+import "github.com/tidwall/gjson"
+
+// VRFs maps BD and EPG DNs to the DN of their VRF, resolved from fvRsCtx and
+// fvRsBd. BDs and EPGs without a resolved relation are not included.
+func VRFs(db *DB) (map[string]string, error) {
+	vrfs := map[string]string{}
+	err := db.FindEach("fvRsCtx:*", func(_ string, rs gjson.Result) bool {
+		if tDn := rs.Get("tDn").Str; tDn != "" {
+			vrfs[ParentDN(rs.Get("dn").Str)] = tDn
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.FindEach("fvRsBd:*", func(_ string, rs gjson.Result) bool {
+		if vrf, ok := vrfs[rs.Get("tDn").Str]; ok {
+			vrfs[ParentDN(rs.Get("dn").Str)] = vrf
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vrfs, nil
+}
@@ -0,0 +1,30 @@
+package mit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRFs(t *testing.T) {
+	a := assert.New(t)
+	src := NewMemSource()
+	src.Add("fvRsCtx", []byte(`{"imdata":[
+		{"fvRsCtx":{"attributes":{"dn":"uni/tn-a/BD-web/rsctx","tDn":"uni/tn-a/ctx-prod"}}},
+		{"fvRsCtx":{"attributes":{"dn":"uni/tn-a/BD-stale/rsctx","tnFvCtxName":"gone","tDn":""}}}
+	]}`))
+	src.Add("fvRsBd", []byte(`{"imdata":[
+		{"fvRsBd":{"attributes":{"dn":"uni/tn-a/ap-shop/epg-web/rsbd","tDn":"uni/tn-a/BD-web"}}},
+		{"fvRsBd":{"attributes":{"dn":"uni/tn-a/ap-shop/epg-old/rsbd","tDn":"uni/tn-a/BD-stale"}}}
+	]}`))
+	db, err := New(src)
+	a.NoError(err)
+	defer db.Close()
+
+	vrfs, err := VRFs(&db)
+	a.NoError(err)
+	a.Equal(map[string]string{
+		"uni/tn-a/BD-web":          "uni/tn-a/ctx-prod",
+		"uni/tn-a/ap-shop/epg-web": "uni/tn-a/ctx-prod",
+	}, vrfs)
+}
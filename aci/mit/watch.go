@@ -0,0 +1,144 @@
+package mit
+
+import (
+	"sync"
+
+	"github.com/tidwall/buntdb"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/match"
+)
+
+// Op is a DB mutation type.
+type Op string
+
+const (
+	// OpSet is a created or updated key.
+	OpSet Op = "set"
+	// OpDelete is a deleted key.
+	OpDelete Op = "delete"
+)
+
+// Event is a DB mutation.
+type Event struct {
+	Op        Op
+	Namespace string
+	// Key is the class:dn key relative to the namespace.
+	Key string
+	// Old is the previous value; it does not exist for new keys.
+	Old gjson.Result
+	// New is the new value; it does not exist for deletes.
+	New gjson.Result
+}
+
+// watcher is a Watch subscription.
+type watcher struct {
+	ns      string
+	pattern string
+	fn      func(Event)
+}
+
+// watchers holds the subscriptions shared by all views of a DB.
+type watchers struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]*watcher
+}
+
+// active reports whether there are any subscriptions.
+func (w *watchers) active() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.subs) > 0
+}
+
+// notify calls matching subscriptions for each event in order.
+func (w *watchers) notify(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	w.mu.RLock()
+	subs := make([]*watcher, 0, len(w.subs))
+	for i := 0; i < w.next; i++ {
+		if sub, ok := w.subs[i]; ok {
+			subs = append(subs, sub)
+		}
+	}
+	w.mu.RUnlock()
+	for _, e := range events {
+		for _, sub := range subs {
+			if sub.ns == e.Namespace && match.Match(e.Key, sub.pattern) {
+				sub.fn(e)
+			}
+		}
+	}
+}
+
+// Watch calls fn for every set or delete of a key matching the pattern in the
+// DB namespace. Events are delivered after the change is committed, in order,
+// on the goroutine that made the change.
+//
+// Watch returns a function to cancel the subscription.
+func (db *DB) Watch(pattern string, fn func(Event)) (cancel func()) {
+	w := db.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.next
+	w.next++
+	w.subs[id] = &watcher{ns: db.ns, pattern: pattern, fn: fn}
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// changes records events in a write transaction for delivery after commit.
+type changes struct {
+	events []Event
+	record bool
+}
+
+// set sets a key in a transaction, recording the change.
+func (c *changes) set(tx *buntdb.Tx, key, value string) error {
+	prev, replaced, err := tx.Set(key, value, nil)
+	if err != nil || !c.record {
+		return err
+	}
+	e := Event{Op: OpSet, New: gjson.Parse(value)}
+	e.Namespace, e.Key = splitKey(key)
+	if replaced {
+		e.Old = gjson.Parse(prev)
+	}
+	c.events = append(c.events, e)
+	return nil
+}
+
+// delete deletes a key in a transaction, recording the change.
+func (c *changes) delete(tx *buntdb.Tx, key string) error {
+	prev, err := tx.Delete(key)
+	if err != nil || !c.record {
+		return err
+	}
+	e := Event{Op: OpDelete, Old: gjson.Parse(prev)}
+	e.Namespace, e.Key = splitKey(key)
+	c.events = append(c.events, e)
+	return nil
+}
+
+// update runs fn in a write transaction and notifies watchers after commit.
+func (db *DB) update(fn func(tx *buntdb.Tx, c *changes) error) error {
+	c := &changes{record: db.watchers.active()}
+	if err := db.db.Update(func(tx *buntdb.Tx) error {
+		c.events = c.events[:0]
+		return fn(tx, c)
+	}); err != nil {
+		return err
+	}
+	if c.record {
+		db.watchers.notify(c.events)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	a := assert.New(t)
+	mit := newTestDB()
+	defer mit.Close()
+
+	var events []Event
+	cancel := mit.Watch("fvTenant:*", func(e Event) {
+		events = append(events, e)
+	})
+
+	// Updates carry the old and new values
+	a.NoError(mit.Set("fvTenant:uni/tn-a", `{"name":"a","descr":"updated"}`))
+	a.Len(events, 1)
+	a.Equal(OpSet, events[0].Op)
+	a.Equal("fvTenant:uni/tn-a", events[0].Key)
+	a.Equal("", events[0].Old.Get("descr").Str)
+	a.Equal("updated", events[0].New.Get("descr").Str)
+
+	// New keys have no old value
+	a.NoError(mit.SetMany(map[string]interface{}{"fvTenant:uni/tn-c": map[string]string{"name": "c"}}))
+	a.NoError(mit.SetRaw(`{"fvTenant:uni/tn-d":{"name":"d"},"fvBD:uni/tn-d/BD-x":{"name":"x"}}`))
+	a.Len(events, 3)
+	a.False(events[1].Old.Exists())
+	a.Equal("c", events[1].New.Get("name").Str)
+	a.Equal("fvTenant:uni/tn-d", events[2].Key)
+
+	// Namespaces are watched separately
+	a.NoError(mit.Namespace("fab2").Set("fvTenant:uni/tn-a", `{"name":"a"}`))
+	a.Len(events, 3)
+	var nsEvents []Event
+	stop := mit.Namespace("fab2").Watch("*", func(e Event) {
+		nsEvents = append(nsEvents, e)
+	})
+	a.NoError(mit.Namespace("fab2").Set("fvTenant:uni/tn-b", `{"name":"b"}`))
+	a.Len(nsEvents, 1)
+	a.Equal("fab2", nsEvents[0].Namespace)
+	stop()
+
+	// Cancel stops delivery
+	cancel()
+	a.NoError(mit.Set("fvTenant:uni/tn-a", `{"name":"a"}`))
+	a.Len(events, 3)
+}
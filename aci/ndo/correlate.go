@@ -0,0 +1,248 @@
+// Package ndo correlates Nexus Dashboard Orchestrator (NDO) data with the APIC MIT.
+package ndo
+
+import (
+	"errors"
+	"sort"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Rule maps an NDO collection to the APIC MOs it deploys.
+type Rule struct {
+	// Collection is the NDO collection, e.g. msc_anpEpgRels
+	Collection string
+	// Class is the APIC class deployed at the DN.
+	Class string
+	// DN is the document path of the deployed DN.
+	DN string
+	// Attrs maps document paths to APIC attributes compared for drift.
+	// Paths missing from a document are not compared, and booleans compare
+	// equal to yes and no.
+	Attrs map[string]string
+	// Children maps document paths holding child DNs to their APIC class.
+	Children map[string]string
+}
+
+// DefaultRules are the rules used when none are passed to Correlate. They
+// map the NDO deployment collections for application profiles, EPGs, BDs,
+// VRFs, contracts and filters to their APIC MOs.
+var DefaultRules = []Rule{
+	{
+		Collection: "msc_anpEpgRels",
+		Class:      "fvAp",
+		DN:         "dn",
+		Children:   map[string]string{"epgs": "fvAEPg"},
+	},
+	{
+		Collection: "msc_bdRels",
+		Class:      "fvBD",
+		DN:         "dn",
+		Attrs: map[string]string{
+			"unicastRouting":           "unicastRoute",
+			"arpFlood":                 "arpFlood",
+			"l2UnknownUnicast":         "unkMacUcastAct",
+			"intersiteBumTrafficAllow": "intersiteBumTrafficAllow",
+		},
+	},
+	{
+		Collection: "msc_vrfRels",
+		Class:      "fvCtx",
+		DN:         "dn",
+		Attrs:      map[string]string{"pcEnfPref": "pcEnfPref", "pcEnfDir": "pcEnfDir"},
+	},
+	{
+		Collection: "msc_contractRels",
+		Class:      "vzBrCP",
+		DN:         "dn",
+		Attrs:      map[string]string{"scope": "scope", "prio": "prio"},
+		Children:   map[string]string{"subjects": "vzSubj"},
+	},
+	{
+		Collection: "msc_filterRels",
+		Class:      "vzFilter",
+		DN:         "dn",
+		Children:   map[string]string{"entries": "vzEntry"},
+	},
+}
+
+// DriftKind is the type of difference between NDO and the APIC.
+type DriftKind string
+
+const (
+	// Missing is an object defined in NDO that is not on the APIC.
+	Missing DriftKind = "missing"
+	// Changed is an object with attributes that differ on the APIC.
+	Changed DriftKind = "changed"
+)
+
+// Link is an NDO object deployed to an APIC MO.
+type Link struct {
+	Collection string
+	OID        string
+	Site       string
+	Class      string
+	DN         string
+	// Schema and Template are the schema ID and template name defining the
+	// MO, and Ref the path of the object in the schema, e.g.
+	// /schemas/<id>/templates/<name>/bds/<name>. They are empty if no template
+	// deployed to the site defines the MO.
+	Schema   string
+	Template string
+	Ref      string
+	// MO is the APIC record; it does not exist for missing MOs.
+	MO gjson.Result
+}
+
+// Drift is a difference between an NDO object and the APIC.
+type Drift struct {
+	Link
+	Kind DriftKind
+	// Attr, Want and Got describe a changed attribute.
+	Attr string
+	Want string
+	Got  string
+}
+
+// Report is the result of correlating NDO with the APIC.
+type Report struct {
+	Links []Link
+	Drift []Drift
+	// Unknown is the sorted list of sites referenced in NDO without APIC data.
+	Unknown []string
+}
+
+// Correlate maps NDO objects to the APIC MOs they deploy, and to the schema
+// template objects that define them.
+//
+// Sites maps NDO site IDs to APIC DBs, e.g. namespaces of a single DB.
+// DefaultRules are used if no rules are passed.
+func Correlate(ndo *mit.DB, sites map[string]*mit.DB, rules ...Rule) (Report, error) {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	var report Report
+	unknown := map[string]bool{}
+	objects, err := schemaObjects(Wrap(ndo))
+	if err != nil {
+		return report, err
+	}
+
+	for _, rule := range rules {
+		docs, err := ndo.Find("%s:*", rule.Collection)
+		if err != nil && !errors.Is(err, mit.ErrEmpty) {
+			return report, err
+		}
+		for _, doc := range docs {
+			siteID := doc.Get("siteId").Str
+			apic, ok := sites[siteID]
+			if !ok {
+				unknown[siteID] = true
+				continue
+			}
+			base := Link{
+				Collection: rule.Collection,
+				OID:        OID(doc),
+				Site:       siteID,
+			}
+			link := base
+			link.Class = rule.Class
+			link.DN = doc.Get(rule.DN).Str
+			link.schema(objects[siteID])
+			if err := report.add(apic, link, doc, rule.Attrs); err != nil {
+				return report, err
+			}
+
+			for path, class := range rule.Children {
+				for _, dn := range doc.Get(path).Array() {
+					child := base
+					child.Class = class
+					child.DN = dn.Str
+					child.schema(objects[siteID])
+					if err := report.add(apic, child, doc, nil); err != nil {
+						return report, err
+					}
+				}
+			}
+		}
+	}
+
+	for site := range unknown {
+		report.Unknown = append(report.Unknown, site)
+	}
+	sort.Strings(report.Unknown)
+	report.sort()
+	return report, nil
+}
+
+// schema sets the schema object of a link from a site's schema objects.
+func (link *Link) schema(objects map[string]schemaObject) {
+	if obj, ok := objects[link.DN]; ok {
+		link.Schema, link.Template, link.Ref = obj.Schema, obj.Template, obj.Ref
+	}
+}
+
+// add looks up a link on the APIC and records any drift.
+func (report *Report) add(apic *mit.DB, link Link, doc gjson.Result, attrs map[string]string) error {
+	mo, err := apic.Get("%s:%s", link.Class, link.DN)
+	if errors.Is(err, mit.ErrNotFound) {
+		report.Links = append(report.Links, link)
+		report.Drift = append(report.Drift, Drift{Link: link, Kind: Missing})
+		return nil
+	} else if err != nil {
+		return err
+	}
+	link.MO = mo
+	report.Links = append(report.Links, link)
+	for path, attr := range attrs {
+		value := doc.Get(path)
+		if !value.Exists() {
+			continue
+		}
+		want, got := attrValue(value), mo.Get(attr).String()
+		if want != got {
+			report.Drift = append(report.Drift, Drift{
+				Link: link,
+				Kind: Changed,
+				Attr: attr,
+				Want: want,
+				Got:  got,
+			})
+		}
+	}
+	return nil
+}
+
+// attrValue returns a document value as an APIC attribute value, e.g. yes for
+// true.
+func attrValue(v gjson.Result) string {
+	switch v.Type {
+	case gjson.True:
+		return "yes"
+	case gjson.False:
+		return "no"
+	}
+	return v.String()
+}
+
+// sort orders links and drift by site and DN.
+func (report *Report) sort() {
+	less := func(a, b Link) bool {
+		if a.Site != b.Site {
+			return a.Site < b.Site
+		}
+		return a.DN < b.DN
+	}
+	sort.SliceStable(report.Links, func(i, j int) bool {
+		return less(report.Links[i], report.Links[j])
+	})
+	sort.SliceStable(report.Drift, func(i, j int) bool {
+		a, b := report.Drift[i], report.Drift[j]
+		if a.DN == b.DN && a.Site == b.Site {
+			return a.Attr < b.Attr
+		}
+		return less(a.Link, b.Link)
+	})
+}
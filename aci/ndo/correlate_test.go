@@ -0,0 +1,107 @@
+package ndo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSite = "5d784dbf10000091016d97af"
+
+func newTestDBs(t *testing.T) (ndo, apic mit.DB) {
+	ndo, err := mit.NewNDO(mit.NewFolderSource(filepath.Join("testdata", "ndo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ndo.Close() })
+	apic, err = mit.New(mit.NewFolderSource(filepath.Join("testdata", "site1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { apic.Close() })
+	return ndo, apic
+}
+
+func TestCorrelate(t *testing.T) {
+	a := assert.New(t)
+	ndo, apic := newTestDBs(t)
+
+	report, err := Correlate(&ndo, map[string]*mit.DB{testSite: &apic})
+	a.NoError(err)
+	a.Len(report.Links, 11)
+	a.Equal("uni/tn-Enterprise/BD-Web", report.Links[0].DN)
+	a.Equal("Web", report.Links[0].MO.Get("name").Str)
+
+	// Links trace back to the schema objects defining them
+	refs := map[string]string{}
+	for _, link := range report.Links {
+		refs[link.DN] = link.Ref
+		if link.Ref != "" {
+			a.Equal(testSchema, link.Schema, link.DN)
+			a.Equal("Template1", link.Template, link.DN)
+		}
+	}
+	schema := "/schemas/" + testSchema + "/templates/Template1"
+	a.Equal(map[string]string{
+		"uni/tn-Enterprise/BD-Web":                     schema + "/bds/Web",
+		"uni/tn-Enterprise/ap-PubSafety":               schema + "/anps/PubSafety",
+		"uni/tn-Enterprise/ap-PubSafety/epg-Migration": schema + "/anps/PubSafety/epgs/Migration",
+		"uni/tn-Enterprise/ap-EDW_clix":                "",
+		"uni/tn-Enterprise/ap-EDW_clix/epg-Prod":       "",
+		"uni/tn-Enterprise/brc-web-app":                schema + "/contracts/web-app",
+		"uni/tn-Enterprise/brc-web-app/subj-http":      "",
+		"uni/tn-Enterprise/ctx-Prod":                   schema + "/vrfs/Prod",
+		"uni/tn-Enterprise/flt-http":                   schema + "/filters/http",
+		"uni/tn-Enterprise/flt-http/e-http":            "",
+		"uni/tn-Enterprise/flt-http/e-https":           "",
+	}, refs)
+	_, err = Wrap(&ndo).Resolve(refs["uni/tn-Enterprise/ap-PubSafety/epg-Migration"])
+	a.NoError(err)
+
+	type drift struct {
+		Kind  DriftKind
+		Class string
+		DN    string
+		Attr  string
+		Want  string
+		Got   string
+	}
+	var res []drift
+	for _, d := range report.Drift {
+		res = append(res, drift{d.Kind, d.Class, d.DN, d.Attr, d.Want, d.Got})
+	}
+	a.Equal([]drift{
+		// arpFlood true matches yes on the APIC
+		{Changed, "fvBD", "uni/tn-Enterprise/BD-Web", "unicastRoute", "yes", "no"},
+		// EDW_clix/Prod is defined in NDO but not deployed
+		{Missing, "fvAEPg", "uni/tn-Enterprise/ap-EDW_clix/epg-Prod", "", "", ""},
+		{Changed, "fvCtx", "uni/tn-Enterprise/ctx-Prod", "pcEnfPref", "enforced", "unenforced"},
+		{Missing, "vzEntry", "uni/tn-Enterprise/flt-http/e-https", "", "", ""},
+	}, res)
+	a.False(report.Drift[1].MO.Exists())
+	a.Equal(schema+"/bds/Web", report.Drift[0].Ref)
+	a.Equal([]string{"5d784dbf10000091016d9999"}, report.Unknown)
+}
+
+func TestCorrelateAttrs(t *testing.T) {
+	a := assert.New(t)
+	ndo, apic := newTestDBs(t)
+
+	report, err := Correlate(&ndo, map[string]*mit.DB{testSite: &apic}, Rule{
+		Collection: "msc_bdRels",
+		Class:      "fvBD",
+		DN:         "dn",
+		Attrs:      map[string]string{"unicastRouting": "unicastRoute"},
+	})
+	a.NoError(err)
+	a.Len(report.Links, 1)
+	a.Len(report.Drift, 1)
+	a.Equal(Changed, report.Drift[0].Kind)
+	a.Equal("unicastRoute", report.Drift[0].Attr)
+	a.Equal("yes", report.Drift[0].Want)
+	a.Equal("no", report.Drift[0].Got)
+	a.Equal([]string{"5d784dbf10000091016d9999"}, report.Unknown)
+}
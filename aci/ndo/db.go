@@ -0,0 +1,147 @@
+package ndo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// NDO collections with typed accessors
+const (
+	Schemas = "msc_schemas"
+	Sites   = "msc_sites"
+	Tenants = "msc_tenants"
+)
+
+// DB is an NDO database with NDO specific lookups.
+type DB struct {
+	*mit.DB
+}
+
+// New creates a new NDO DB from a source of NDO collections.
+func New(src mit.Source) (DB, error) {
+	db, err := mit.NewNDO(src)
+	return DB{&db}, err
+}
+
+// Wrap wraps an NDO mit.DB, e.g. a namespace of a larger DB.
+func Wrap(db *mit.DB) DB {
+	return DB{db}
+}
+
+// OID returns the ID of a document as keyed in the DB, or an empty string if
+// it has none.
+func OID(doc gjson.Result) string {
+	id, _ := mit.DocumentID(doc)
+	return id
+}
+
+// ByOID finds a document by ID in any collection.
+func (db DB) ByOID(oid string) (collection string, doc gjson.Result, err error) {
+	keys, err := db.Keys("*:%s", oid)
+	if err != nil {
+		return "", doc, err
+	}
+	if len(keys) == 0 {
+		return "", doc, fmt.Errorf("NDO:OID:%s:%w", oid, mit.ErrNotFound)
+	}
+	collection = keys[0][:strings.Index(keys[0], ":")]
+	doc, err = db.Get("%s", keys[0])
+	return collection, doc, err
+}
+
+// BySite returns the documents in a collection for a site.
+// Documents match on a siteId field, or a site listed in sites or
+// siteAssociations, e.g. schemas and tenants.
+func (db DB) BySite(collection, siteID string) ([]gjson.Result, error) {
+	name := collection + ":siteId"
+	if err := db.CreateIndex(name, collection+":*", "siteId"); err != nil {
+		return nil, err
+	}
+	res, err := db.FindIndex(name, siteID)
+	if err != nil || len(res) > 0 {
+		return res, err
+	}
+	docs, err := db.Find("%s:*", collection)
+	if err != nil && !errors.Is(err, mit.ErrEmpty) {
+		return nil, err
+	}
+	for _, doc := range docs {
+		for _, path := range []string{"sites", "siteAssociations"} {
+			if doc.Get(fmt.Sprintf("%s.#(siteId==%q)", path, siteID)).Exists() {
+				res = append(res, doc)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// Resolve resolves a reference to the document or object it refers to.
+// References are either a document ID or a schema path, e.g.
+//
+//	/schemas/<id>/templates/<name>/anps/<name>/epgs/<name>
+func (db DB) Resolve(ref string) (gjson.Result, error) {
+	if !strings.HasPrefix(ref, "/") {
+		_, doc, err := db.ByOID(ref)
+		return doc, err
+	}
+	parts := strings.Split(strings.Trim(ref, "/"), "/")
+	if len(parts) < 2 || len(parts)%2 != 0 || parts[0] != "schemas" {
+		return gjson.Result{}, fmt.Errorf("NDO:REF:%s:invalid reference", ref)
+	}
+	obj, err := db.Get("%s:%s", Schemas, parts[1])
+	if err != nil {
+		return obj, err
+	}
+	for i := 2; i < len(parts); i += 2 {
+		obj = obj.Get(fmt.Sprintf("%s.#(name==%q)", parts[i], parts[i+1]))
+		if !obj.Exists() {
+			return obj, fmt.Errorf("NDO:REF:%s:%s %s not found", ref, parts[i], parts[i+1])
+		}
+	}
+	return obj, nil
+}
+
+// Schema finds a schema by display name.
+func (db DB) Schema(name string) (gjson.Result, error) {
+	index := Schemas + ":displayName"
+	if err := db.CreateIndex(index, Schemas+":*", "displayName"); err != nil {
+		return gjson.Result{}, err
+	}
+	res, err := db.FindIndex(index, name)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	if len(res) == 0 {
+		return gjson.Result{}, fmt.Errorf("NDO:SCHEMA:%s:%w", name, mit.ErrNotFound)
+	}
+	return res[0], nil
+}
+
+// Site returns a site by ID.
+func (db DB) Site(id string) (gjson.Result, error) {
+	return db.Get("%s:%s", Sites, id)
+}
+
+// Tenant returns the tenant of a schema template.
+func (db DB) Tenant(template gjson.Result) (gjson.Result, error) {
+	return db.Get("%s:%s", Tenants, template.Get("tenantId").Str)
+}
+
+// TemplateSites returns the sites a schema template is deployed to.
+func (db DB) TemplateSites(schema gjson.Result, template string) (res []gjson.Result, err error) {
+	query := fmt.Sprintf("sites.#(templateName==%q)#.siteId", template)
+	for _, id := range schema.Get(query).Array() {
+		site, err := db.Site(id.Str)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, site)
+	}
+	return res, nil
+}
@@ -0,0 +1,116 @@
+package ndo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+const testSchema = "5ee97b394174748e7dd70001"
+
+func newTestNDO(t *testing.T) DB {
+	db, err := New(mit.NewFolderSource(filepath.Join("testdata", "ndo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestByOID(t *testing.T) {
+	a := assert.New(t)
+	db := newTestNDO(t)
+	collection, doc, err := db.ByOID(testSite)
+	a.NoError(err)
+	a.Equal(Sites, collection)
+	a.Equal("Site1", doc.Get("name").Str)
+	a.Equal(testSite, OID(doc))
+
+	_, _, err = db.ByOID("000000000000000000000000")
+	a.ErrorIs(err, mit.ErrNotFound)
+
+	// Documents keyed by other extended JSON IDs
+	src := mit.NewMemSource()
+	src.Add("msc_audit", []byte(`{"_id":{"$numberLong":"42"},"name":"a"}`+"\n"+
+		`{"_id":{"$uuid":"0f6e0fb7-6b5c-4d1e-9c1a-2a8f1f3b4c5d"},"name":"b"}`))
+	db, err = New(src)
+	a.NoError(err)
+	defer db.Close()
+	for _, id := range []string{"42", "0f6e0fb7-6b5c-4d1e-9c1a-2a8f1f3b4c5d"} {
+		_, doc, err := db.ByOID(id)
+		a.NoError(err)
+		a.Equal(id, OID(doc))
+	}
+	a.Empty(OID(gjson.Parse(`{"name":"no id"}`)))
+}
+
+func TestBySite(t *testing.T) {
+	a := assert.New(t)
+	db := newTestNDO(t)
+
+	// Top-level siteId
+	res, err := db.BySite("msc_anpEpgRels", testSite)
+	a.NoError(err)
+	a.Len(res, 2)
+
+	// Sites listed in the document
+	res, err = db.BySite(Schemas, testSite)
+	a.NoError(err)
+	a.Len(res, 1)
+	res, err = db.BySite(Tenants, testSite)
+	a.NoError(err)
+	a.Len(res, 1)
+	res, err = db.BySite(Schemas, "000000000000000000000000")
+	a.NoError(err)
+	a.Empty(res)
+}
+
+func TestResolve(t *testing.T) {
+	a := assert.New(t)
+	db := newTestNDO(t)
+
+	schema, err := db.Schema("Schema1")
+	a.NoError(err)
+	epg := schema.Get("templates.0.anps.0.epgs.0")
+
+	// Schema path references
+	bd, err := db.Resolve(epg.Get("bdRef").Str)
+	a.NoError(err)
+	a.Equal("Web", bd.Get("name").Str)
+	vrf, err := db.Resolve(bd.Get("vrfRef").Str)
+	a.NoError(err)
+	a.Equal("Prod", vrf.Get("name").Str)
+	_, err = db.Resolve("/schemas/" + testSchema + "/templates/Template1/bds/Missing")
+	a.Error(err)
+	_, err = db.Resolve("/schemas/" + testSchema + "/templates")
+	a.Error(err)
+
+	// ID references
+	tenant, err := db.Resolve(schema.Get("templates.0.tenantId").Str)
+	a.NoError(err)
+	a.Equal("Enterprise", tenant.Get("name").Str)
+
+	// Schemas -> templates -> sites
+	tenant, err = db.Tenant(schema.Get("templates.0"))
+	a.NoError(err)
+	a.Equal("Enterprise", tenant.Get("name").Str)
+	sites, err := db.TemplateSites(schema, "Template1")
+	a.NoError(err)
+	a.Len(sites, 2)
+	a.Equal("Site1", sites[0].Get("name").Str)
+
+	_, err = db.Schema("Missing")
+	a.ErrorIs(err, mit.ErrNotFound)
+}
+
+func TestWrap(t *testing.T) {
+	a := assert.New(t)
+	db := newTestNDO(t)
+	site, err := Wrap(db.DB).Site(testSite)
+	a.NoError(err)
+	a.Equal("Site1", site.Get("name").Str)
+}
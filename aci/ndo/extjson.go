@@ -0,0 +1,78 @@
+package ndo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Decode converts a MongoDB extended JSON value to Go values.
+//
+// $date values become time.Time, $numberLong and $numberInt become int64,
+// $numberDouble becomes float64 and $oid becomes a string. Objects and arrays
+// are decoded recursively to maps and slices.
+func Decode(j gjson.Result) interface{} {
+	switch {
+	case j.IsArray():
+		res := []interface{}{}
+		for _, v := range j.Array() {
+			res = append(res, Decode(v))
+		}
+		return res
+	case j.IsObject():
+		if t, ok := Time(j); ok {
+			return t
+		}
+		if oid := j.Get("$oid"); oid.Exists() {
+			return oid.Str
+		}
+		for _, key := range []string{"$numberLong", "$numberInt"} {
+			if n := j.Get(key); n.Exists() {
+				return n.Int()
+			}
+		}
+		if n := j.Get("$numberDouble"); n.Exists() {
+			return n.Float()
+		}
+		res := map[string]interface{}{}
+		j.ForEach(func(k, v gjson.Result) bool {
+			res[k.Str] = Decode(v)
+			return true
+		})
+		return res
+	default:
+		return j.Value()
+	}
+}
+
+// Time decodes a $date value in canonical or relaxed form.
+func Time(j gjson.Result) (time.Time, bool) {
+	date := j.Get("$date")
+	if !date.Exists() {
+		return time.Time{}, false
+	}
+	switch {
+	case date.Get("$numberLong").Exists():
+		ms, err := strconv.ParseInt(date.Get("$numberLong").Str, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(ms).UTC(), true
+	case date.Type == gjson.Number:
+		return time.UnixMilli(date.Int()).UTC(), true
+	default:
+		t, err := time.Parse(time.RFC3339Nano, date.Str)
+		return t, err == nil
+	}
+}
+
+// Int decodes an integer in canonical, e.g. {"$numberLong":"1"}, or relaxed form.
+func Int(j gjson.Result) int64 {
+	for _, key := range []string{"$numberLong", "$numberInt"} {
+		if n := j.Get(key); n.Exists() {
+			return n.Int()
+		}
+	}
+	return j.Int()
+}
@@ -0,0 +1,50 @@
+package ndo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestTime(t *testing.T) {
+	a := assert.New(t)
+	want := time.Date(2020, 6, 16, 20, 13, 45, 123000000, time.UTC)
+	for _, j := range []string{
+		`{"$date":"2020-06-16T20:13:45.123Z"}`,
+		`{"$date":{"$numberLong":"1592338425123"}}`,
+		`{"$date":1592338425123}`,
+	} {
+		res, ok := Time(gjson.Parse(j))
+		a.True(ok, j)
+		a.True(want.Equal(res), j)
+	}
+	_, ok := Time(gjson.Parse(`"2020-06-16"`))
+	a.False(ok)
+}
+
+func TestDecode(t *testing.T) {
+	a := assert.New(t)
+	res := Decode(gjson.Parse(`{
+		"_id": {"$oid": "5ee97b394174748e7dd70001"},
+		"count": {"$numberLong": "42"},
+		"small": {"$numberInt": "7"},
+		"ratio": {"$numberDouble": "0.5"},
+		"created": {"$date": "2020-06-16T20:13:45.123Z"},
+		"tags": ["a", {"$numberLong": "1"}],
+		"name": "x"
+	}`))
+	doc, ok := res.(map[string]interface{})
+	a.True(ok)
+	a.Equal("5ee97b394174748e7dd70001", doc["_id"])
+	a.Equal(int64(42), doc["count"])
+	a.Equal(int64(7), doc["small"])
+	a.Equal(0.5, doc["ratio"])
+	a.IsType(time.Time{}, doc["created"])
+	a.Equal([]interface{}{"a", int64(1)}, doc["tags"])
+	a.Equal("x", doc["name"])
+
+	a.Equal(int64(1), Int(gjson.Parse(`{"$numberLong":"1"}`)))
+	a.Equal(int64(2), Int(gjson.Parse(`2`)))
+}
@@ -0,0 +1,86 @@
+package ndo
+
+import (
+	"errors"
+	"fmt"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// schemaObject is the schema template object that deploys an APIC MO.
+type schemaObject struct {
+	Schema   string
+	Template string
+	Ref      string
+}
+
+// schemaType is a list of template objects and the RN prefix of the MOs they
+// deploy. Nested lists are deployed under their parent, e.g. EPGs in an ANP.
+type schemaType struct {
+	path   string
+	prefix string
+	nested []schemaType
+}
+
+var schemaTypes = []schemaType{
+	{path: "anps", prefix: "ap-", nested: []schemaType{{path: "epgs", prefix: "epg-"}}},
+	{path: "bds", prefix: "BD-"},
+	{path: "vrfs", prefix: "ctx-"},
+	{path: "contracts", prefix: "brc-"},
+	{path: "filters", prefix: "flt-"},
+}
+
+// schemaObjects maps the APIC DNs deployed by schema templates to their
+// schema object, by site ID. Templates are only mapped for the sites they are
+// deployed to, and templates without a known tenant are skipped.
+func schemaObjects(ndo DB) (map[string]map[string]schemaObject, error) {
+	res := map[string]map[string]schemaObject{}
+	schemas, err := ndo.Find("%s:*", Schemas)
+	if errors.Is(err, mit.ErrEmpty) {
+		return res, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, schema := range schemas {
+		for _, template := range schema.Get("templates").Array() {
+			tenant, err := ndo.Tenant(template)
+			if errors.Is(err, mit.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			base := schemaObject{Schema: OID(schema), Template: template.Get("name").Str}
+			objects := map[string]schemaObject{}
+			addObjects(objects, base, schemaTypes, template,
+				"uni/tn-"+tenant.Get("name").Str,
+				fmt.Sprintf("/schemas/%s/templates/%s", base.Schema, base.Template))
+
+			query := fmt.Sprintf("sites.#(templateName==%q)#.siteId", base.Template)
+			for _, site := range schema.Get(query).Array() {
+				if res[site.Str] == nil {
+					res[site.Str] = map[string]schemaObject{}
+				}
+				for dn, obj := range objects {
+					res[site.Str][dn] = obj
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// addObjects adds the objects of a template or parent object by DN, e.g.
+// uni/tn-a/BD-web for /schemas/<id>/templates/<name>/bds/web.
+func addObjects(objects map[string]schemaObject, base schemaObject, types []schemaType, parent gjson.Result, dn, ref string) {
+	for _, t := range types {
+		for _, obj := range parent.Get(t.path).Array() {
+			name := obj.Get("name").Str
+			o := base
+			o.Ref = ref + "/" + t.path + "/" + name
+			objects[dn+"/"+t.prefix+name] = o
+			addObjects(objects, base, t.nested, obj, dn+"/"+t.prefix+name, o.Ref)
+		}
+	}
+}
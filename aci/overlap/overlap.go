@@ -0,0 +1,186 @@
+// Package overlap finds overlapping IP subnets and VLAN ranges in the MIT.
+package overlap
+
+import (
+	"net/netip"
+	"sort"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Conflict kinds
+const (
+	// Subnet is a BD or EPG subnet overlapping another in the same VRF.
+	Subnet = "subnet"
+	// L3Out is an L3Out external EPG subnet overlapping a BD or EPG subnet.
+	L3Out = "l3out"
+	// VLAN is an encap block overlapping another on the same AEP.
+	VLAN = "vlan"
+)
+
+// Conflict is a pair of overlapping ranges.
+type Conflict struct {
+	Kind string
+	// Scope is the VRF for subnets and the AEP for VLANs.
+	Scope string
+	// A and B are the DNs of the overlapping objects, and RangeA and
+	// RangeB their ranges, e.g. 10.0.0.0/16 or vlan-100-vlan-199.
+	A      string
+	B      string
+	RangeA string
+	RangeB string
+}
+
+// All returns the subnet, L3Out and VLAN conflicts.
+func All(db *mit.DB) ([]Conflict, error) {
+	var res []Conflict
+	for _, fn := range []func(*mit.DB) ([]Conflict, error){Subnets, L3OutSubnets, VLANs} {
+		conflicts, err := fn(db)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, conflicts...)
+	}
+	return res, nil
+}
+
+// subnet is a parsed subnet and the DN it is defined on.
+type subnet struct {
+	dn     string
+	prefix netip.Prefix
+}
+
+// Subnets finds BD and EPG subnets that overlap within a VRF. Subnets of BDs
+// without a resolved VRF are ignored.
+func Subnets(db *mit.DB) ([]Conflict, error) {
+	byVRF, err := fabricSubnets(db)
+	if err != nil {
+		return nil, err
+	}
+	var res []Conflict
+	for _, vrf := range sortedKeys(byVRF) {
+		subnets := byVRF[vrf]
+		// Prefixes either nest or are disjoint, so after sorting each prefix
+		// overlaps the run of prefixes following it that it contains.
+		for i, a := range subnets {
+			for _, b := range subnets[i+1:] {
+				if !a.prefix.Contains(b.prefix.Addr()) {
+					break
+				}
+				res = append(res, conflict(Subnet, vrf, a, b))
+			}
+		}
+	}
+	return res, nil
+}
+
+// L3OutSubnets finds L3Out external EPG subnets that overlap BD or EPG
+// subnets in the L3Out's VRF. Default routes and L3Outs without a resolved
+// VRF are ignored.
+func L3OutSubnets(db *mit.DB) ([]Conflict, error) {
+	byVRF, err := fabricSubnets(db)
+	if err != nil {
+		return nil, err
+	}
+	outVRF := map[string]string{}
+	err = db.FindEach("l3extRsEctx:*", func(_ string, rs gjson.Result) bool {
+		outVRF[mit.ParentDN(rs.Get("dn").Str)] = rs.Get("tDn").Str
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var external []subnet
+	err = db.FindEach("l3extSubnet:*", func(_ string, v gjson.Result) bool {
+		if s, ok := parseSubnet(v); ok && s.prefix.Bits() > 0 {
+			external = append(external, s)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortSubnets(external)
+	var res []Conflict
+	for _, ext := range external {
+		// uni/tn-a/out-b/instP-c/extsubnet-[...]
+		vrf := outVRF[mit.ParentDN(mit.ParentDN(ext.dn))]
+		if vrf == "" {
+			continue
+		}
+		for _, s := range byVRF[vrf] {
+			if ext.prefix.Overlaps(s.prefix) {
+				res = append(res, conflict(L3Out, vrf, ext, s))
+			}
+		}
+	}
+	return res, nil
+}
+
+// fabricSubnets returns the BD and EPG subnets by VRF, sorted by prefix.
+// Subnets without a resolved VRF are skipped, as they cannot overlap.
+func fabricSubnets(db *mit.DB) (map[string][]subnet, error) {
+	vrfs, err := mit.VRFs(db)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]subnet{}
+	err = db.FindEach("fvSubnet:*", func(_ string, v gjson.Result) bool {
+		if s, ok := parseSubnet(v); ok {
+			if vrf := vrfs[mit.ParentDN(s.dn)]; vrf != "" {
+				res[vrf] = append(res[vrf], s)
+			}
+		}
+		return true
+	})
+	for _, subnets := range res {
+		sortSubnets(subnets)
+	}
+	return res, err
+}
+
+// parseSubnet parses a subnet's ip, e.g. a gateway address 10.0.0.1/24, to
+// its network prefix.
+func parseSubnet(v gjson.Result) (subnet, bool) {
+	prefix, err := netip.ParsePrefix(v.Get("ip").Str)
+	if err != nil {
+		return subnet{}, false
+	}
+	return subnet{dn: v.Get("dn").Str, prefix: prefix.Masked()}, true
+}
+
+// sortSubnets sorts subnets by address, then by prefix length.
+func sortSubnets(subnets []subnet) {
+	sort.SliceStable(subnets, func(i, j int) bool {
+		a, b := subnets[i].prefix, subnets[j].prefix
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c < 0
+		}
+		if a.Bits() != b.Bits() {
+			return a.Bits() < b.Bits()
+		}
+		return subnets[i].dn < subnets[j].dn
+	})
+}
+
+func conflict(kind, scope string, a, b subnet) Conflict {
+	return Conflict{
+		Kind:   kind,
+		Scope:  scope,
+		A:      a.dn,
+		B:      b.dn,
+		RangeA: a.prefix.String(),
+		RangeB: b.prefix.String(),
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
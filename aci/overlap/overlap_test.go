@@ -0,0 +1,83 @@
+package overlap
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubnets(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := Subnets(db)
+	a.NoError(err)
+	a.Equal([]Conflict{
+		{
+			Kind:   Subnet,
+			Scope:  "uni/tn-Ent/ctx-prod",
+			A:      "uni/tn-Ent/BD-web/subnet-[10.1.0.1/16]",
+			B:      "uni/tn-Ent/BD-app/subnet-[10.1.2.1/24]",
+			RangeA: "10.1.0.0/16",
+			RangeB: "10.1.2.0/24",
+		},
+		{
+			Kind:   Subnet,
+			Scope:  "uni/tn-Ent/ctx-prod",
+			A:      "uni/tn-Ent/BD-db/subnet-[10.2.0.1/24]",
+			B:      "uni/tn-Ent/ap-shop/epg-app/subnet-[10.2.0.129/25]",
+			RangeA: "10.2.0.0/24",
+			RangeB: "10.2.0.128/25",
+		},
+	}, res)
+}
+
+func TestL3OutSubnets(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := L3OutSubnets(db)
+	a.NoError(err)
+	a.Len(res, 2)
+	for _, c := range res {
+		a.Equal(L3Out, c.Kind)
+		a.Equal("uni/tn-Ent/ctx-prod", c.Scope)
+		a.Equal("uni/tn-Ent/out-inet/instP-partners/extsubnet-[10.2.0.0/23]", c.A)
+		a.Equal("10.2.0.0/23", c.RangeA)
+	}
+	a.Equal("uni/tn-Ent/BD-db/subnet-[10.2.0.1/24]", res[0].B)
+	a.Equal("uni/tn-Ent/ap-shop/epg-app/subnet-[10.2.0.129/25]", res[1].B)
+}
+
+func TestVLANs(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := VLANs(db)
+	a.NoError(err)
+	a.Equal([]Conflict{
+		{
+			Kind:   VLAN,
+			Scope:  "uni/infra/attentp-servers",
+			A:      "uni/infra/vlanns-[phys]-static/from-[vlan-100]-to-[vlan-199]",
+			B:      "uni/infra/vlanns-[l3]-static/from-[vlan-150]-to-[vlan-160]",
+			RangeA: "vlan-100-vlan-199",
+			RangeB: "vlan-150-vlan-160",
+		},
+		{
+			Kind:   VLAN,
+			Scope:  "uni/infra/attentp-servers",
+			A:      "uni/infra/vlanns-[vmm]-dynamic/from-[vlan-1000]-to-[vlan-1999]",
+			B:      "uni/infra/vlanns-[vmm]-dynamic/from-[vlan-1500]-to-[vlan-1500]",
+			RangeA: "vlan-1000-vlan-1999",
+			RangeB: "vlan-1500-vlan-1500",
+		},
+	}, res)
+}
+
+func TestAll(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	res, err := All(db)
+	a.NoError(err)
+	a.Len(res, 6)
+}
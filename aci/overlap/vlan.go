@@ -0,0 +1,93 @@
+package overlap
+
+import (
+	"fmt"
+	"sort"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// encapBlock is a VLAN range in a pool.
+type encapBlock struct {
+	dn       string
+	pool     string
+	from, to int
+}
+
+func (b encapBlock) String() string {
+	return fmt.Sprintf("vlan-%d-vlan-%d", b.from, b.to)
+}
+
+// VLANs finds encap blocks that overlap on the same AEP, i.e. blocks in the
+// VLAN pools of the domains attached to an AEP, including blocks in the
+// same pool.
+func VLANs(db *mit.DB) ([]Conflict, error) {
+	blocks := map[string][]encapBlock{}
+	err := db.FindEach("fvnsEncapBlk:*", func(_ string, v gjson.Result) bool {
+		from, errFrom := mit.VLANID(v.Get("from").Str)
+		to, errTo := mit.VLANID(v.Get("to").Str)
+		if errFrom == nil && errTo == nil {
+			dn := v.Get("dn").Str
+			pool := mit.ParentDN(dn)
+			blocks[pool] = append(blocks[pool], encapBlock{dn: dn, pool: pool, from: from, to: to})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	domainPool := map[string]string{}
+	err = db.FindEach("infraRsVlanNs:*", func(_ string, rs gjson.Result) bool {
+		domainPool[mit.ParentDN(rs.Get("dn").Str)] = rs.Get("tDn").Str
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	aepPools := map[string]map[string]bool{}
+	err = db.FindEach("infraRsDomP:*", func(_ string, rs gjson.Result) bool {
+		aep := mit.ParentDN(rs.Get("dn").Str)
+		if pool, ok := domainPool[rs.Get("tDn").Str]; ok {
+			if aepPools[aep] == nil {
+				aepPools[aep] = map[string]bool{}
+			}
+			aepPools[aep][pool] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Conflict
+	for _, aep := range sortedKeys(aepPools) {
+		var all []encapBlock
+		for pool := range aepPools[aep] {
+			all = append(all, blocks[pool]...)
+		}
+		sort.Slice(all, func(i, j int) bool {
+			if all[i].from != all[j].from {
+				return all[i].from < all[j].from
+			}
+			return all[i].dn < all[j].dn
+		})
+		for i, a := range all {
+			for _, b := range all[i+1:] {
+				if b.from > a.to {
+					break
+				}
+				res = append(res, Conflict{
+					Kind:   VLAN,
+					Scope:  aep,
+					A:      a.dn,
+					B:      b.dn,
+					RangeA: a.String(),
+					RangeB: b.String(),
+				})
+			}
+		}
+	}
+	return res, nil
+}
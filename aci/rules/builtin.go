@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lib/aci/contracts"
+	"lib/aci/mit"
+	"lib/aci/overlap"
+
+	"github.com/tidwall/gjson"
+)
+
+// Builtin are the starter best-practice rules.
+var Builtin = []Rule{
+	BDWithoutSubnet,
+	EPGWithoutBD,
+	VRFWithoutContracts,
+	MCPDisabled,
+	DuplicateSubnets,
+	UnusedPolicies,
+}
+
+// BDWithoutSubnet finds BDs with unicast routing enabled and no subnet.
+var BDWithoutSubnet = Check{
+	Info: Meta{
+		ID:          "bd-unicast-no-subnet",
+		Description: "BD with unicast routing enabled but no subnet",
+		Severity:    Warning,
+		Classes:     []string{"fvBD", "fvSubnet"},
+		Remediation: "Add a subnet to the BD or disable unicast routing for L2 only BDs.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		return db.View(func(tx *mit.Tx) error {
+			var err error
+			if ferr := tx.FindEach("fvBD:*", func(_ string, bd gjson.Result) bool {
+				if bd.Get("unicastRoute").Str != "yes" {
+					return true
+				}
+				dn := bd.Get("dn").Str
+				var n int
+				if n, err = tx.Count("fvSubnet:%s/subnet-*", dn); err != nil {
+					return false
+				}
+				if n == 0 {
+					emit(dn, fmt.Sprintf("BD %s has unicast routing enabled but no subnet", bd.Get("name").Str))
+				}
+				return true
+			}); ferr != nil {
+				return ferr
+			}
+			return err
+		})
+	},
+}
+
+// EPGWithoutBD finds EPGs without a resolved BD.
+var EPGWithoutBD = Check{
+	Info: Meta{
+		ID:          "epg-without-bd",
+		Description: "EPG not associated with an existing BD",
+		Severity:    Critical,
+		Classes:     []string{"fvAEPg", "fvRsBd"},
+		Remediation: "Associate the EPG with a BD.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		return db.View(func(tx *mit.Tx) error {
+			var err error
+			if ferr := tx.FindEach("fvAEPg:*", func(_ string, epg gjson.Result) bool {
+				dn := epg.Get("dn").Str
+				var rs gjson.Result
+				rs, err = tx.Get("fvRsBd:%s/rsbd", dn)
+				switch {
+				case errors.Is(err, mit.ErrNotFound):
+					err = nil
+					emit(dn, fmt.Sprintf("EPG %s has no BD", epg.Get("name").Str))
+				case err != nil:
+					return false
+				case rs.Get("state").Str == "missing-target":
+					// An empty name resolves to the default BD in tenant common
+					name := rs.Get("tnFvBDName").Str
+					if name == "" {
+						name = "default"
+					}
+					emit(dn, fmt.Sprintf("EPG %s references missing BD %q", epg.Get("name").Str, name))
+				}
+				return true
+			}); ferr != nil {
+				return ferr
+			}
+			return err
+		})
+	},
+}
+
+// VRFWithoutContracts finds enforced VRFs where no EPG or vzAny provides or
+// consumes a contract, i.e. all traffic between EPGs is dropped.
+var VRFWithoutContracts = Check{
+	Info: Meta{
+		ID:          "vrf-enforced-no-contracts",
+		Description: "Enforced VRF without contracts",
+		Severity:    Warning,
+		Classes:     []string{"fvCtx", "fvRsCtx"},
+		Remediation: "Add contracts between the VRF's EPGs, or set the VRF to unenforced.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		g, err := contracts.New(db)
+		if err != nil {
+			return err
+		}
+		used := map[string]bool{}
+		for _, epg := range g.EPGs {
+			if len(epg.Provides) > 0 || len(epg.Consumes) > 0 {
+				used[epg.VRF] = true
+			}
+		}
+		for dn, vrf := range g.VRFs {
+			if vrf.Enforced && !used[dn] && len(vrf.Provides) == 0 && len(vrf.Consumes) == 0 {
+				emit(dn, fmt.Sprintf("VRF %s is enforced but has no contracts", strings.TrimPrefix(mit.LastRN(dn), "ctx-")))
+			}
+		}
+		return nil
+	},
+}
+
+// MCPDisabled finds MCP disabled globally.
+var MCPDisabled = Check{
+	Info: Meta{
+		ID:          "mcp-disabled",
+		Description: "MisCabling Protocol (MCP) disabled globally",
+		Severity:    Warning,
+		Classes:     []string{"mcpInstPol"},
+		Remediation: "Enable MCP in Fabric > Access Policies > Global Policies to detect loops.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		return db.FindEach("mcpInstPol:*", func(_ string, pol gjson.Result) bool {
+			if pol.Get("adminSt").Str == "disabled" {
+				emit(pol.Get("dn").Str, "MCP is disabled globally")
+			}
+			return true
+		})
+	},
+}
+
+// DuplicateSubnets finds BD and EPG subnets with the same network in a VRF.
+// Each duplicate is reported once, against the first subnet with the network.
+var DuplicateSubnets = Check{
+	Info: Meta{
+		ID:          "duplicate-subnets",
+		Description: "Duplicate subnet in a VRF",
+		Severity:    Critical,
+		Classes:     []string{"fvSubnet", "fvRsCtx"},
+		Remediation: "Remove the duplicate subnet or move it to a different VRF.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		conflicts, err := overlap.Subnets(db)
+		if err != nil {
+			return err
+		}
+		reported := map[string]bool{}
+		for _, c := range conflicts {
+			if c.RangeA != c.RangeB || reported[c.B] {
+				continue
+			}
+			reported[c.B] = true
+			emit(c.B, fmt.Sprintf("subnet %s duplicates %s", c.RangeB, c.A))
+		}
+		return nil
+	},
+}
+
+// UnusedPolicies finds contracts that are not provided or consumed, and
+// filters not used by any contract subject. Policies named default are
+// ignored.
+var UnusedPolicies = Check{
+	Info: Meta{
+		ID:          "unused-policies",
+		Description: "Unused contract or filter",
+		Severity:    Info,
+		Classes:     []string{"vzBrCP", "vzFilter"},
+		Remediation: "Remove unused policies to simplify the configuration.",
+	},
+	Fn: func(db *mit.DB, emit func(dn, message string)) error {
+		g, err := contracts.New(db)
+		if err != nil {
+			return err
+		}
+		used := map[string]bool{}
+		use := func(dns ...[]string) {
+			for _, list := range dns {
+				for _, dn := range list {
+					used[dn] = true
+				}
+			}
+		}
+		for _, epg := range g.EPGs {
+			use(epg.Provides, epg.Consumes)
+		}
+		for _, vrf := range g.VRFs {
+			use(vrf.Provides, vrf.Consumes)
+		}
+		for _, c := range g.Contracts {
+			for _, s := range c.Subjects {
+				for _, ref := range s.Filters {
+					used[ref.Filter] = true
+				}
+			}
+		}
+		for dn, c := range g.Contracts {
+			if !used[dn] && c.Name != "default" {
+				emit(dn, fmt.Sprintf("contract %s is not provided or consumed", c.Name))
+			}
+		}
+		for dn := range g.Filters {
+			if name := strings.TrimPrefix(mit.LastRN(dn), "flt-"); !used[dn] && name != "default" {
+				emit(dn, fmt.Sprintf("filter %s is not used by any contract", name))
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,298 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// expr is a compiled filter expression, e.g.
+//
+//	unicastRoute == "yes" && (subnets.# == 0 || !ctx)
+//
+// Identifiers are gjson paths into the MO and its joins. A bare identifier
+// is true if it exists and is not empty. Comparisons are numeric when both
+// sides are numbers; =~ and !~ match regular expressions.
+type expr interface {
+	eval(doc gjson.Result) bool
+}
+
+// ExprError is an error in a filter expression.
+type ExprError struct {
+	// Col is the 1-based column of the error in the expression.
+	Col int
+	Msg string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Col, e.Msg)
+}
+
+type token struct {
+	kind string // ident, string, number, op or eof
+	text string
+	pos  int
+}
+
+// tokenize splits an expression into tokens.
+func tokenize(s string) ([]token, error) {
+	var res []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, &ExprError{Col: i + 1, Msg: "unterminated string"}
+			}
+			res = append(res, token{"string", b.String(), i})
+			i = j + 1
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			res = append(res, token{"number", s[i:j], i})
+			i = j
+		case isIdent(c):
+			j := i
+			for j < len(s) && (isIdent(s[j]) || s[j] >= '0' && s[j] <= '9' || s[j] == '.' || s[j] == '#') {
+				j++
+			}
+			res = append(res, token{"ident", s[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &ExprError{Col: i + 1, Msg: fmt.Sprintf("unexpected %q", c)}
+			}
+			res = append(res, token{"op", op, i})
+			i += len(op)
+		}
+	}
+	return append(res, token{"eof", "", len(s)}), nil
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parser is a recursive descent expression parser.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseExpr compiles a filter expression.
+func parseExpr(s string) (expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, a ...interface{}) error {
+	return &ExprError{Col: t.pos + 1, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *parser) or() (expr, error) {
+	left, err := p.and()
+	for err == nil && p.peek().text == "||" {
+		p.next()
+		var right expr
+		if right, err = p.and(); err == nil {
+			left = orExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.unary()
+	for err == nil && p.peek().text == "&&" {
+		p.next()
+		var right expr
+		if right, err = p.unary(); err == nil {
+			left = andExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) unary() (expr, error) {
+	if t := p.peek(); t.kind == "op" && t.text == "!" {
+		p.next()
+		e, err := p.unary()
+		return notExpr{e}, err
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.next()
+	if t.kind == "op" && t.text == "(" {
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if close := p.next(); close.text != ")" {
+			return nil, p.errorf(close, "expected )")
+		}
+		return e, nil
+	}
+	left, err := p.operand(t)
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.operand(p.next())
+		return compareExpr{op.text, left, right}, err
+	case "=~", "!~":
+		p.next()
+		lit := p.next()
+		if lit.kind != "string" {
+			return nil, p.errorf(lit, "%s expects a string pattern", op.text)
+		}
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, p.errorf(lit, "invalid pattern: %v", err)
+		}
+		return matchExpr{left, re, op.text == "!~"}, nil
+	}
+	if left.path == "" {
+		return nil, p.errorf(t, "expected a comparison")
+	}
+	return existsExpr{left.path}, nil
+}
+
+func (p *parser) operand(t token) (operand, error) {
+	switch t.kind {
+	case "ident":
+		return operand{path: t.text}, nil
+	case "string", "number":
+		return operand{lit: t.text}, nil
+	case "eof":
+		return operand{}, p.errorf(t, "unexpected end of expression")
+	}
+	return operand{}, p.errorf(t, "unexpected %q", t.text)
+}
+
+// operand is a path into the document or a literal.
+type operand struct {
+	path string
+	lit  string
+}
+
+func (o operand) value(doc gjson.Result) string {
+	if o.path != "" {
+		return doc.Get(o.path).String()
+	}
+	return o.lit
+}
+
+type orExpr struct{ left, right expr }
+
+func (e orExpr) eval(doc gjson.Result) bool { return e.left.eval(doc) || e.right.eval(doc) }
+
+type andExpr struct{ left, right expr }
+
+func (e andExpr) eval(doc gjson.Result) bool { return e.left.eval(doc) && e.right.eval(doc) }
+
+type notExpr struct{ e expr }
+
+func (e notExpr) eval(doc gjson.Result) bool { return !e.e.eval(doc) }
+
+type existsExpr struct{ path string }
+
+func (e existsExpr) eval(doc gjson.Result) bool {
+	v := doc.Get(e.path)
+	return v.Exists() && v.String() != ""
+}
+
+type matchExpr struct {
+	left   operand
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (e matchExpr) eval(doc gjson.Result) bool {
+	return e.re.MatchString(e.left.value(doc)) != e.negate
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e compareExpr) eval(doc gjson.Result) bool {
+	a, b := e.left.value(doc), e.right.value(doc)
+	cmp := strings.Compare(a, b)
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch e.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
@@ -0,0 +1,163 @@
+// Package rules runs best-practice checks against the MIT in a mit.DB.
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"lib/aci/mit"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+// Finding severities
+const (
+	Critical Severity = "critical"
+	Warning  Severity = "warning"
+	Info     Severity = "info"
+)
+
+// Meta describes a rule.
+type Meta struct {
+	ID          string
+	Description string
+	Severity    Severity
+	// Classes are the classes the rule reads. Rules are skipped if a class
+	// is not loaded in the DB.
+	Classes     []string
+	Remediation string
+}
+
+// Finding is an issue found by a rule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	// DN is the affected MO.
+	DN          string
+	Message     string
+	Remediation string
+}
+
+// Rule is a check run against a DB.
+type Rule interface {
+	Meta() Meta
+	Run(db *mit.DB) ([]Finding, error)
+}
+
+// Check is a rule implemented by a function. The function reports findings
+// with emit, e.g.
+//
+//	rules.Check{
+//		Info: rules.Meta{ID: "tenant-count", Severity: rules.Info, Classes: []string{"fvTenant"}},
+//		Fn: func(db *mit.DB, emit func(dn, message string)) error {
+//			emit("uni", "tenants found")
+//			return nil
+//		},
+//	}
+type Check struct {
+	Info Meta
+	Fn   func(db *mit.DB, emit func(dn, message string)) error
+}
+
+// Meta returns the rule description.
+func (c Check) Meta() Meta {
+	return c.Info
+}
+
+// Run runs the check.
+func (c Check) Run(db *mit.DB) (res []Finding, err error) {
+	err = c.Fn(db, func(dn, message string) {
+		res = append(res, c.Info.finding(dn, message))
+	})
+	return res, err
+}
+
+// finding creates a finding for the rule.
+func (m Meta) finding(dn, message string) Finding {
+	return Finding{
+		Rule:        m.ID,
+		Severity:    m.Severity,
+		DN:          dn,
+		Message:     message,
+		Remediation: m.Remediation,
+	}
+}
+
+// Skipped is a rule that was not run.
+type Skipped struct {
+	Rule string
+	// Missing are the classes the rule needs that are not loaded.
+	Missing []string
+}
+
+// Report is the result of running rules.
+type Report struct {
+	Findings []Finding
+	Skipped  []Skipped
+}
+
+// Engine runs a set of rules.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine creates an engine with rules, e.g.
+//
+//	rules.NewEngine(rules.Builtin...).Run(db)
+func NewEngine(rules ...Rule) *Engine {
+	e := &Engine{}
+	e.Register(rules...)
+	return e
+}
+
+// Register adds rules to the engine.
+func (e *Engine) Register(rules ...Rule) {
+	e.rules = append(e.rules, rules...)
+}
+
+// Rules returns the registered rules.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Run runs every rule with its classes loaded in the DB.
+// Findings are sorted by rule and DN.
+func (e *Engine) Run(db *mit.DB) (Report, error) {
+	var report Report
+	loaded := map[string]bool{}
+	for _, c := range db.Collections() {
+		loaded[c.Class] = true
+	}
+	for _, rule := range e.rules {
+		meta := rule.Meta()
+		var missing []string
+		for _, class := range meta.Classes {
+			if loaded[class] {
+				continue
+			}
+			if n, err := db.Count("%s:*", class); err != nil {
+				return report, err
+			} else if n == 0 {
+				missing = append(missing, class)
+			}
+		}
+		if len(missing) > 0 {
+			report.Skipped = append(report.Skipped, Skipped{Rule: meta.ID, Missing: missing})
+			continue
+		}
+		findings, err := rule.Run(db)
+		if err != nil {
+			return report, fmt.Errorf("RULES:%s:%w", meta.ID, err)
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.DN < b.DN
+	})
+	return report, nil
+}
@@ -0,0 +1,187 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// findings returns the DNs found by each rule.
+func findings(report Report) map[string][]string {
+	res := map[string][]string{}
+	for _, f := range report.Findings {
+		res[f.Rule] = append(res[f.Rule], f.DN)
+	}
+	return res
+}
+
+func TestBuiltin(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	report, err := NewEngine(Builtin...).Run(db)
+	a.NoError(err)
+	a.Empty(report.Skipped)
+	a.Equal(map[string][]string{
+		"bd-unicast-no-subnet":      {"uni/tn-Ent/BD-l2"},
+		"duplicate-subnets":         {"uni/tn-Ent/ap-shop/epg-app/subnet-[10.1.1.254/24]"},
+		"epg-without-bd":            {"uni/tn-Ent/ap-shop/epg-lab", "uni/tn-Ent/ap-shop/epg-orphan"},
+		"mcp-disabled":              {"uni/infra/mcpInstP-default"},
+		"unused-policies":           {"uni/tn-Ent/brc-old", "uni/tn-Ent/flt-legacy"},
+		"vrf-enforced-no-contracts": {"uni/tn-Ent/ctx-empty"},
+	}, findings(report))
+
+	f := report.Findings[0]
+	a.Equal(Warning, f.Severity)
+	a.Equal("BD l2 has unicast routing enabled but no subnet", f.Message)
+	a.Equal(BDWithoutSubnet.Info.Remediation, f.Remediation)
+	for _, f := range report.Findings {
+		if f.Rule == "duplicate-subnets" {
+			a.Equal("subnet 10.1.1.0/24 duplicates uni/tn-Ent/BD-web/subnet-[10.1.1.1/24]", f.Message)
+		}
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	a := assert.New(t)
+	db, err := mit.New(mit.NewMemSource())
+	a.NoError(err)
+	defer db.Close()
+	a.NoError(db.Set("fvBD:uni/tn-a/BD-b", `{"dn":"uni/tn-a/BD-b","name":"b","unicastRoute":"yes"}`))
+
+	report, err := NewEngine(BDWithoutSubnet, MCPDisabled).Run(&db)
+	a.NoError(err)
+	a.Empty(report.Findings)
+	a.Equal([]Skipped{
+		{Rule: "bd-unicast-no-subnet", Missing: []string{"fvSubnet"}},
+		{Rule: "mcp-disabled", Missing: []string{"mcpInstPol"}},
+	}, report.Skipped)
+}
+
+// newEmptyDB returns a DB with empty collections for classes.
+func newEmptyDB(t *testing.T, classes ...string) *mit.DB {
+	src := mit.NewMemSource()
+	for _, class := range classes {
+		src.Add(class, []byte(`{"totalCount":"0","imdata":[]}`))
+	}
+	db, err := mit.New(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &db
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	a := assert.New(t)
+	db := newEmptyDB(t, "fvSubnet", "fvRsBd")
+	for i := 0; i < 10000; i++ {
+		dn := fmt.Sprintf("uni/tn-a/BD-%d", i)
+		a.NoError(db.Set("fvBD:"+dn, fmt.Sprintf(`{"dn":%q,"unicastRoute":"yes"}`, dn)))
+		epg := fmt.Sprintf("uni/tn-a/ap-p/epg-%d", i)
+		a.NoError(db.Set("fvAEPg:"+epg, fmt.Sprintf(`{"dn":%q}`, epg)))
+	}
+
+	// Rules must not block on a writer waiting for their read lock. The DB
+	// is not closed on failure, as Close would block too.
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				db.Set("fvTenant:uni/tn-b", `{"dn":"uni/tn-b"}`)
+			}
+		}
+	}()
+	done := make(chan error)
+	go func() {
+		report, err := NewEngine(BDWithoutSubnet, EPGWithoutBD).Run(db)
+		if err == nil && len(report.Skipped) > 0 {
+			err = fmt.Errorf("skipped: %v", report.Skipped)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		a.NoError(err)
+		close(stop)
+		db.Close()
+	case <-time.After(10 * time.Second):
+		t.Fatal("rules deadlocked with a concurrent writer")
+	}
+}
+
+func TestNoSubnetsOrBDs(t *testing.T) {
+	a := assert.New(t)
+	db := newEmptyDB(t, "fvSubnet", "fvRsBd")
+	defer db.Close()
+	a.NoError(db.Set("fvBD:uni/tn-a/BD-b", `{"dn":"uni/tn-a/BD-b","name":"b","unicastRoute":"yes"}`))
+	a.NoError(db.Set("fvAEPg:uni/tn-a/ap-p/epg-e", `{"dn":"uni/tn-a/ap-p/epg-e","name":"e"}`))
+
+	// Collected classes without MOs are loaded
+	report, err := NewEngine(BDWithoutSubnet, EPGWithoutBD).Run(db)
+	a.NoError(err)
+	a.Empty(report.Skipped)
+	a.Equal(map[string][]string{
+		"bd-unicast-no-subnet": {"uni/tn-a/BD-b"},
+		"epg-without-bd":       {"uni/tn-a/ap-p/epg-e"},
+	}, findings(report))
+
+	// An empty BD name resolves to common/default
+	a.NoError(db.Set("fvRsBd:uni/tn-a/ap-p/epg-e/rsbd", `{"dn":"uni/tn-a/ap-p/epg-e/rsbd","tnFvBDName":"","state":"formed"}`))
+	report, err = NewEngine(EPGWithoutBD).Run(db)
+	a.NoError(err)
+	a.Empty(report.Findings)
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	e := NewEngine()
+	e.Register(Check{
+		Info: Meta{ID: "custom", Severity: Info, Classes: []string{"fvCtx"}},
+		Fn: func(db *mit.DB, emit func(dn, message string)) error {
+			emit("uni/tn-Ent", "custom finding")
+			return nil
+		},
+	}, Check{
+		Info: Meta{ID: "broken"},
+		Fn: func(*mit.DB, func(string, string)) error {
+			return errors.New("broken")
+		},
+	})
+	a.Len(e.Rules(), 2)
+	report, err := e.Run(db)
+	a.EqualError(err, "RULES:broken:broken")
+	a.Equal([]Finding{{Rule: "custom", Severity: Info, DN: "uni/tn-Ent", Message: "custom finding"}}, report.Findings)
+}
+
+func TestDuplicateSubnets(t *testing.T) {
+	a := assert.New(t)
+	db, err := mit.New(mit.NewMemSource())
+	a.NoError(err)
+	defer db.Close()
+	for _, bd := range []string{"a", "b", "c"} {
+		a.NoError(db.Set("fvRsCtx:uni/tn-t/BD-"+bd+"/rsctx", `{"dn":"uni/tn-t/BD-`+bd+`/rsctx","tDn":"uni/tn-t/ctx-v"}`))
+		dn := "uni/tn-t/BD-" + bd + "/subnet-[10.0.0.1/24]"
+		a.NoError(db.Set("fvSubnet:"+dn, `{"dn":"`+dn+`","ip":"10.0.0.1/24"}`))
+	}
+	// Nested subnets overlap but are not duplicates
+	a.NoError(db.Set("fvSubnet:uni/tn-t/BD-a/subnet-[10.0.0.1/16]", `{"dn":"uni/tn-t/BD-a/subnet-[10.0.0.1/16]","ip":"10.0.0.1/16"}`))
+
+	report, err := NewEngine(DuplicateSubnets).Run(&db)
+	a.NoError(err)
+	a.Equal(map[string][]string{"duplicate-subnets": {
+		"uni/tn-t/BD-b/subnet-[10.0.0.1/24]",
+		"uni/tn-t/BD-c/subnet-[10.0.0.1/24]",
+	}}, findings(report))
+	for _, f := range report.Findings {
+		a.Equal("subnet 10.0.0.0/24 duplicates uni/tn-t/BD-a/subnet-[10.0.0.1/24]", f.Message)
+	}
+}
@@ -0,0 +1,338 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"lib/aci/mit"
+	"lib/json"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadError is an error in a rule file.
+type LoadError struct {
+	File string
+	// Line and Col locate the error; Col is 0 if unknown.
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *LoadError) Error() string {
+	if e.Col > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// yamlLine extracts the line number from yaml.v3 syntax errors.
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
+// templateVar matches {{path}} in templates.
+var templateVar = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+
+// Join adds related MOs to a YAML rule's document.
+type Join struct {
+	// As is the name of the join in filters and messages.
+	As    string
+	Class string
+	// DN is a template for the DN of a single related MO, e.g. {{dn}}/rsctx.
+	DN string
+	// Under is a template for a DN; all MOs of the class below it are
+	// joined as an array, e.g. subnets.# is the subnet count.
+	Under string
+}
+
+// YAMLRule is a rule declared in YAML. The filter is evaluated against each
+// MO of the class, with joins added as nested objects, e.g.
+//
+//	rules:
+//	  - id: bd-no-subnet
+//	    severity: warning
+//	    class: fvBD
+//	    join:
+//	      - as: subnets
+//	        class: fvSubnet
+//	        under: "{{dn}}"
+//	    filter: unicastRoute == "yes" && subnets.# == 0
+//	    message: BD {{name}} has no subnet
+type YAMLRule struct {
+	Info    Meta
+	Class   string
+	Joins   []Join
+	Filter  string
+	Message string
+	filter  expr
+}
+
+// Meta returns the rule description.
+func (r *YAMLRule) Meta() Meta {
+	return r.Info
+}
+
+// Run evaluates the rule against each MO of the class.
+func (r *YAMLRule) Run(db *mit.DB) (res []Finding, err error) {
+	var keys []string
+	err = db.FindEach(r.Class+":*", func(key string, _ gjson.Result) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		mo, err := db.Get("%s", key)
+		if err != nil {
+			return res, err
+		}
+		doc, err := r.join(db, mo.Raw)
+		if err != nil {
+			return res, err
+		}
+		if r.filter == nil || r.filter.eval(doc) {
+			res = append(res, r.Info.finding(mo.Get("dn").Str, expand(r.Message, doc)))
+		}
+	}
+	return res, nil
+}
+
+// join adds the joined MOs to an MO document.
+func (r *YAMLRule) join(db *mit.DB, doc string) (gjson.Result, error) {
+	for _, j := range r.Joins {
+		cur := gjson.Parse(doc)
+		if j.DN != "" {
+			v, err := db.Get("%s:%s", j.Class, expand(j.DN, cur))
+			if errors.Is(err, mit.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return cur, err
+			}
+			doc = json.SetRaw(doc, j.As, v.Raw)
+			continue
+		}
+		under := strings.TrimSuffix(expand(j.Under, cur), "/")
+		all := "[]"
+		err := db.FindEach(j.Class+":"+under+"/*", func(_ string, v gjson.Result) bool {
+			all = json.SetRaw(all, "-1", v.Raw)
+			return true
+		})
+		if err != nil {
+			return cur, err
+		}
+		doc = json.SetRaw(doc, j.As, all)
+	}
+	return gjson.Parse(doc), nil
+}
+
+// expand replaces {{path}} in a template with values from a document.
+func expand(template string, doc gjson.Result) string {
+	return templateVar.ReplaceAllStringFunc(template, func(s string) string {
+		return doc.Get(templateVar.FindStringSubmatch(s)[1]).String()
+	})
+}
+
+// LoadYAML loads the rules in a YAML file.
+func LoadYAML(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseYAML(path, data)
+}
+
+// ParseYAML parses YAML rule definitions. The file name is used in errors.
+// Rules are either a list or a list under a rules key.
+func ParseYAML(file string, data []byte) ([]Rule, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		line, msg := 0, strings.TrimPrefix(err.Error(), "yaml: ")
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = strings.Replace(msg, m[0], "", 1)
+		}
+		return nil, &LoadError{File: file, Line: line, Msg: msg}
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	l := loader{file: file, ids: map[string]int{}}
+	list := root.Content[0]
+	if list.Kind == yaml.MappingNode {
+		rules := l.field(list, "rules")
+		if rules == nil {
+			return nil, l.errorf(list, "missing rules")
+		}
+		for i := 0; i < len(list.Content); i += 2 {
+			if key := list.Content[i]; key.Value != "rules" {
+				return nil, l.errorf(key, "unknown key %q", key.Value)
+			}
+		}
+		list = rules
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, l.errorf(list, "rules must be a list")
+	}
+	var res []Rule
+	for _, node := range list.Content {
+		rule, err := l.rule(node)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rule)
+	}
+	return res, nil
+}
+
+// loader parses rules from YAML nodes.
+type loader struct {
+	file string
+	// ids maps rule IDs to their line
+	ids map[string]int
+}
+
+func (l *loader) errorf(node *yaml.Node, format string, a ...interface{}) error {
+	return &LoadError{File: l.file, Line: node.Line, Col: node.Column, Msg: fmt.Sprintf(format, a...)}
+}
+
+// field returns the value node for a key in a mapping.
+func (l *loader) field(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// fields reads the string fields of a mapping, rejecting unknown keys.
+// Keys listed in nested are returned as nodes.
+func (l *loader) fields(node *yaml.Node, known []string, nested ...string) (map[string]string, map[string]*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil, l.errorf(node, "expected a mapping")
+	}
+	values, nodes := map[string]string{}, map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch {
+		case contains(nested, key.Value):
+			nodes[key.Value] = value
+		case contains(known, key.Value):
+			if value.Kind != yaml.ScalarNode {
+				return nil, nil, l.errorf(value, "%s must be a string", key.Value)
+			}
+			values[key.Value] = value.Value
+			nodes[key.Value] = value
+		default:
+			return nil, nil, l.errorf(key, "unknown key %q", key.Value)
+		}
+	}
+	return values, nodes, nil
+}
+
+func (l *loader) rule(node *yaml.Node) (*YAMLRule, error) {
+	known := []string{"id", "description", "severity", "class", "filter", "message", "remediation"}
+	v, nodes, err := l.fields(node, known, "join")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"id", "class", "message"} {
+		if v[key] == "" {
+			return nil, l.errorf(node, "missing %s", key)
+		}
+	}
+	if line, ok := l.ids[v["id"]]; ok {
+		return nil, l.errorf(nodes["id"], "duplicate rule %q, first defined on line %d", v["id"], line)
+	}
+	l.ids[v["id"]] = nodes["id"].Line
+
+	r := &YAMLRule{
+		Info: Meta{
+			ID:          v["id"],
+			Description: v["description"],
+			Severity:    Warning,
+			Classes:     []string{v["class"]},
+			Remediation: v["remediation"],
+		},
+		Class:   v["class"],
+		Filter:  v["filter"],
+		Message: v["message"],
+	}
+	if sev, ok := v["severity"]; ok {
+		switch Severity(sev) {
+		case Critical, Warning, Info:
+			r.Info.Severity = Severity(sev)
+		default:
+			return nil, l.errorf(nodes["severity"], "invalid severity %q", sev)
+		}
+	}
+	if r.Filter != "" {
+		if r.filter, err = parseExpr(r.Filter); err != nil {
+			return nil, l.exprError(nodes["filter"], err)
+		}
+	}
+	if joins, ok := nodes["join"]; ok {
+		if joins.Kind != yaml.SequenceNode {
+			return nil, l.errorf(joins, "join must be a list")
+		}
+		for _, node := range joins.Content {
+			j, err := l.join(node)
+			if err != nil {
+				return nil, err
+			}
+			r.Joins = append(r.Joins, j)
+			if !contains(r.Info.Classes, j.Class) {
+				r.Info.Classes = append(r.Info.Classes, j.Class)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (l *loader) join(node *yaml.Node) (Join, error) {
+	v, _, err := l.fields(node, []string{"as", "class", "dn", "under"})
+	if err != nil {
+		return Join{}, err
+	}
+	j := Join{As: v["as"], Class: v["class"], DN: v["dn"], Under: v["under"]}
+	switch {
+	case j.As == "":
+		return j, l.errorf(node, "missing as")
+	case j.Class == "":
+		return j, l.errorf(node, "missing class")
+	case (j.DN == "") == (j.Under == ""):
+		return j, l.errorf(node, "join needs one of dn or under")
+	}
+	return j, nil
+}
+
+// exprError locates an expression error in the file. Columns are exact for
+// plain and quoted single line filters.
+func (l *loader) exprError(node *yaml.Node, err error) error {
+	var e *ExprError
+	if !errors.As(err, &e) {
+		return l.errorf(node, "invalid filter: %v", err)
+	}
+	col := node.Column + e.Col - 1
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		col++
+	}
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || strings.Contains(node.Value, "\n") {
+		col = 0
+	}
+	return &LoadError{File: l.file, Line: node.Line, Col: col, Msg: "invalid filter: " + e.Msg}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package rules
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadYAML(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	rules, err := LoadYAML(filepath.Join("testdata", "yaml", "tenant.yaml"))
+	a.NoError(err)
+	a.Len(rules, 3)
+
+	meta := rules[0].Meta()
+	a.Equal("yaml-bd-no-subnet", meta.ID)
+	a.Equal(Warning, meta.Severity)
+	a.Equal([]string{"fvBD", "fvSubnet", "fvRsCtx"}, meta.Classes)
+
+	report, err := NewEngine(rules...).Run(db)
+	a.NoError(err)
+	a.Equal(map[string][]string{
+		"yaml-bd-no-subnet":  {"uni/tn-Ent/BD-l2"},
+		"yaml-epg-no-bd":     {"uni/tn-Ent/ap-shop/epg-lab", "uni/tn-Ent/ap-shop/epg-orphan"},
+		"yaml-public-subnet": {"uni/tn-Ent/BD-web/subnet-[10.1.1.1/24]"},
+	}, findings(report))
+	a.Equal("BD l2 in VRF prod has no subnet", report.Findings[0].Message)
+	a.Equal("Add a subnet or disable unicast routing.", report.Findings[0].Remediation)
+	a.Equal(Critical, report.Findings[1].Severity)
+	a.Equal("Subnet 10.1.1.1/24 is advertised outside the fabric", report.Findings[3].Message)
+}
+
+func TestYAMLJoins(t *testing.T) {
+	a := assert.New(t)
+	rules, err := LoadYAML(filepath.Join("testdata", "yaml", "tenant.yaml"))
+	a.NoError(err)
+
+	// Joined classes must be loaded
+	db, err := mit.New(mit.NewMemSource())
+	a.NoError(err)
+	defer db.Close()
+	a.NoError(db.Set("fvBD:uni/tn-a/BD-b", `{"dn":"uni/tn-a/BD-b","name":"b","unicastRoute":"yes"}`))
+	report, err := NewEngine(rules...).Run(&db)
+	a.NoError(err)
+	a.Empty(report.Findings)
+	a.Equal([]Skipped{
+		{Rule: "yaml-bd-no-subnet", Missing: []string{"fvSubnet", "fvRsCtx"}},
+		{Rule: "yaml-epg-no-bd", Missing: []string{"fvAEPg", "fvRsBd"}},
+		{Rule: "yaml-public-subnet", Missing: []string{"fvSubnet"}},
+	}, report.Skipped)
+
+	// Collected without MOs
+	edb := newEmptyDB(t, "fvSubnet", "fvRsCtx", "fvRsBd")
+	defer edb.Close()
+	a.NoError(edb.Set("fvBD:uni/tn-a/BD-b", `{"dn":"uni/tn-a/BD-b","name":"b","unicastRoute":"yes"}`))
+	a.NoError(edb.Set("fvAEPg:uni/tn-a/ap-p/epg-e", `{"dn":"uni/tn-a/ap-p/epg-e","name":"e"}`))
+	report, err = NewEngine(rules...).Run(edb)
+	a.NoError(err)
+	a.Empty(report.Skipped)
+	a.Equal(map[string][]string{
+		"yaml-bd-no-subnet": {"uni/tn-a/BD-b"},
+		"yaml-epg-no-bd":    {"uni/tn-a/ap-p/epg-e"},
+	}, findings(report))
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	a := assert.New(t)
+	for _, tc := range []struct {
+		yaml string
+		line int
+		col  int
+		msg  string
+	}{
+		{"rules:\n  - id: a\n    class: fvBD\n    mesage: typo\n", 4, 5, `unknown key "mesage"`},
+		{"rules:\n  - id: a\n    class: fvBD\n", 2, 5, "missing message"},
+		{"rules:\n  - id: a\n    class: fvBD\n    severity: bad\n    message: m\n", 4, 15, `invalid severity "bad"`},
+		{"rules:\n  - id: a\n    class: fvBD\n    message: m\n    filter: name == \n", 5, 20, "invalid filter: unexpected end of expression"},
+		{"rules:\n  - id: a\n    class: fvBD\n    message: m\n    filter: 'name =~ \"[\"'\n", 5, 22, "invalid filter: invalid pattern: error parsing regexp: missing closing ]: `[`"},
+		{"rules:\n  - id: a\n    class: fvBD\n    message: m\n  - id: a\n    class: fvBD\n    message: m\n", 5, 9, `duplicate rule "a", first defined on line 2`},
+		{"rules:\n  - id: a\n    class: fvBD\n    message: m\n    join:\n      - as: x\n        class: fvRsCtx\n", 6, 9, "join needs one of dn or under"},
+		{"rules:\n  - id: a\n   class: fvBD\n", 2, 0, "did not find expected '-' indicator"},
+		{"checks: []\n", 1, 1, "missing rules"},
+	} {
+		_, err := ParseYAML("test.yaml", []byte(tc.yaml))
+		var lerr *LoadError
+		if !a.True(errors.As(err, &lerr), tc.yaml) {
+			continue
+		}
+		a.Equal("test.yaml", lerr.File)
+		a.Equal(tc.line, lerr.Line, tc.msg)
+		a.Equal(tc.col, lerr.Col, tc.msg)
+		a.Equal(tc.msg, lerr.Msg)
+	}
+
+	_, err := ParseYAML("test.yaml", []byte("rules:\n  - id: a\n    class: fvBD\n    mesage: typo\n"))
+	a.EqualError(err, `test.yaml:4:5: unknown key "mesage"`)
+
+	rules, err := ParseYAML("test.yaml", []byte("- id: a\n  class: fvBD\n  message: m\n"))
+	a.NoError(err)
+	a.Len(rules, 1)
+}
+
+func TestExpr(t *testing.T) {
+	a := assert.New(t)
+	doc := `{"name":"web","count":"10","subnets":[{"ip":"10.0.0.1/24"}],"flag":""}`
+	for expr, want := range map[string]bool{
+		`name == "web"`:                   true,
+		`name != 'web'`:                   false,
+		`count > 9`:                       true,
+		`count <= 9.5`:                    false,
+		`subnets.# == 1`:                  true,
+		`subnets.0.ip =~ "^10\\."`:        true,
+		`name !~ "^w"`:                    false,
+		`flag`:                            false,
+		`!missing && name`:                true,
+		`(name == "x" || count == 10)`:    true,
+		`name == "x" || !(count == "10")`: false,
+	} {
+		e, err := parseExpr(expr)
+		if !a.NoError(err, expr) {
+			continue
+		}
+		a.Equal(want, e.eval(gjson.Parse(doc)), expr)
+	}
+	for expr, col := range map[string]int{
+		`name ==`:       8,
+		`name == "x`:    9,
+		`(name == "x"`:  13,
+		`name @ "x"`:    6,
+		`"x"`:           1,
+		`name == "x" )`: 13,
+		`name =~ other`: 9,
+	} {
+		_, err := parseExpr(expr)
+		var e *ExprError
+		if a.True(errors.As(err, &e), expr) {
+			a.Equal(col, e.Col, expr)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package scale
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// limitData holds the verified scalability limits per APIC release, with the
+// Verified Scalability Guide each release's limits are taken from. Releases
+// without an entry use the limits of the latest earlier release.
+//
+//go:embed limits.json
+var limitData string
+
+// Release is the verified scalability limits of an APIC release.
+type Release struct {
+	// Name is the release, e.g. 5.2.
+	Name string
+	// Source is the Verified Scalability Guide the limits are taken from.
+	Source string
+	// LeafProfile is the forwarding scale profile the per-leaf limits are
+	// verified with. Leaf limits depend on the leaf model and profile; they are
+	// those of the default profile, and differ for e.g. the high LPM profile.
+	LeafProfile string
+	// Fabric maps fabric-wide metrics to their limit.
+	Fabric map[string]int
+	// Leaf maps per-leaf metrics to their limit.
+	Leaf map[string]int
+}
+
+// Limits maps APIC releases to their limits.
+var Limits = func() map[string]Release {
+	res := map[string]Release{}
+	for name, v := range gjson.Parse(limitData).Map() {
+		rel := Release{
+			Name:        name,
+			Source:      v.Get("source").Str,
+			LeafProfile: v.Get("leafProfile").Str,
+			Fabric:      map[string]int{},
+			Leaf:        map[string]int{},
+		}
+		for k, n := range v.Get("fabric").Map() {
+			rel.Fabric[k] = int(n.Int())
+		}
+		for k, n := range v.Get("leaf").Map() {
+			rel.Leaf[k] = int(n.Int())
+		}
+		res[name] = rel
+	}
+	return res
+}()
+
+// versionRe matches the major and minor release of an APIC version,
+// e.g. 5.2(7f) or apic-5.2(7f).
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// parseVersion returns the major and minor release of a version.
+func parseVersion(version string) (major, minor int, ok bool) {
+	m := versionRe.FindStringSubmatch(version)
+	if m == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, true
+}
+
+// LimitsFor returns the limits for an APIC version, i.e. those of the latest
+// release not newer than the version.
+func LimitsFor(version string) (Release, error) {
+	major, minor, ok := parseVersion(version)
+	if !ok {
+		return Release{}, fmt.Errorf("SCALE:VERSION:%s:invalid version", version)
+	}
+	names := make([]string, 0, len(Limits))
+	for name := range Limits {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return releaseLess(names[j], names[i]) })
+	for _, name := range names {
+		relMajor, relMinor, _ := parseVersion(name)
+		if relMajor < major || (relMajor == major && relMinor <= minor) {
+			return Limits[name], nil
+		}
+	}
+	return Release{}, fmt.Errorf("SCALE:VERSION:%s:%w", version, mit.ErrNotFound)
+}
+
+// releaseLess orders releases numerically, e.g. 4.2 before 10.0.
+func releaseLess(a, b string) bool {
+	aMajor, aMinor, _ := parseVersion(a)
+	bMajor, bMinor, _ := parseVersion(b)
+	if aMajor != bMajor {
+		return aMajor < bMajor
+	}
+	return aMinor < bMinor
+}
+
+// Version returns the APIC version from firmwareCtrlrRunning, or the
+// controllers' topSystem if firmware is not loaded.
+func Version(db *mit.DB) (string, error) {
+	var version string
+	for _, class := range []string{"firmwareCtrlrRunning", "topSystem"} {
+		if err := db.FindEach(class+":*", func(_ string, v gjson.Result) bool {
+			if class == "topSystem" && v.Get("role").Str != "controller" {
+				return true
+			}
+			version = v.Get("version").Str
+			return version == ""
+		}); err != nil {
+			return "", err
+		}
+		if version != "" {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("SCALE:VERSION:%w", mit.ErrNotFound)
+}
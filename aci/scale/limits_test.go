@@ -0,0 +1,59 @@
+package scale
+
+import (
+	"errors"
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitsFor(t *testing.T) {
+	a := assert.New(t)
+	a.Contains(Limits, "5.2")
+	a.Equal(21000, Limits["5.2"].Fabric["epgs"])
+	for name, rel := range Limits {
+		a.Contains(rel.Source, "Verified Scalability Guide, Release "+name, name)
+		a.Equal("default", rel.LeafProfile, name)
+	}
+
+	for version, name := range map[string]string{
+		"5.2(7f)":      "5.2",
+		"apic-5.2(1g)": "5.2",
+		"5.3(2b)":      "5.2",
+		"6.0(3d)":      "5.2",
+		"4.2(7w)":      "4.2",
+		"10.0(1a)":     "5.2",
+	} {
+		rel, err := LimitsFor(version)
+		a.NoError(err, version)
+		a.Equal(name, rel.Name, version)
+	}
+
+	_, err := LimitsFor("3.2(9h)")
+	a.True(errors.Is(err, mit.ErrNotFound))
+	_, err = LimitsFor("bogus")
+	a.Error(err)
+}
+
+func TestVersion(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	version, err := Version(db)
+	a.NoError(err)
+	a.Equal("5.2(7f)", version)
+
+	// Fall back to the controllers' topSystem
+	_, err = db.DeletePattern("firmwareCtrlrRunning:*")
+	a.NoError(err)
+	version, err = Version(db)
+	a.NoError(err)
+	a.Equal("5.2(7f)", version)
+
+	_, err = db.DeletePattern("topSystem:*")
+	a.NoError(err)
+	_, err = Version(db)
+	a.True(errors.Is(err, mit.ErrNotFound))
+}
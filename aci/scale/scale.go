@@ -0,0 +1,185 @@
+// Package scale compares object counts in a mit.DB to the verified
+// scalability limits of the APIC release.
+package scale
+
+import (
+	"sort"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Scope is whether a metric is counted for the fabric or per leaf.
+type Scope string
+
+// Metric scopes
+const (
+	Fabric Scope = "fabric"
+	Leaf   Scope = "leaf"
+)
+
+// Metric is a counted object type and the class it is counted from.
+type Metric struct {
+	Name  string
+	Class string
+	Scope Scope
+}
+
+// Metrics are the metrics in the report.
+var Metrics = []Metric{
+	{"tenants", "fvTenant", Fabric},
+	{"vrfs", "fvCtx", Fabric},
+	{"bds", "fvBD", Fabric},
+	{"epgs", "fvAEPg", Fabric},
+	{"contracts", "vzBrCP", Fabric},
+	{"filters", "vzFilter", Fabric},
+	{"l3outs", "l3extOut", Fabric},
+	{"macEndpoints", "epmMacEp", Leaf},
+	{"ipEndpoints", "epmIpEp", Leaf},
+	{"policyCAM", "actrlRule", Leaf},
+	{"vlans", "vlanCktEp", Leaf},
+}
+
+// Item is the count of a metric against its limit.
+type Item struct {
+	Metric string
+	Scope  Scope
+	// Node is the leaf ID for per-leaf metrics.
+	Node  string
+	Count int
+	Limit int
+	// Percent is the count as a percentage of the limit.
+	Percent float64
+}
+
+// Report is the scale of a fabric against the limits of its release.
+type Report struct {
+	// Version is the APIC version the limits were selected for.
+	Version string
+	// Release is the release of the limits, e.g. 5.2.
+	Release string
+	// Source is the Verified Scalability Guide the limits are taken from.
+	Source string
+	// Items are sorted by metric order, then node.
+	Items []Item
+	// Skipped are metrics with their class not loaded.
+	Skipped []string
+}
+
+// Over returns the items at or above a percentage of their limit,
+// highest first.
+func (r Report) Over(percent float64) []Item {
+	var res []Item
+	for _, item := range r.Items {
+		if item.Percent >= percent {
+			res = append(res, item)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Percent > res[j].Percent })
+	return res
+}
+
+// Options are report options.
+type Options struct {
+	// Version overrides the APIC version read from the DB.
+	Version string
+}
+
+// ForVersion selects the limits for an APIC version, e.g. 5.2(7f),
+// instead of the version read from the DB.
+func ForVersion(version string) func(*Options) {
+	return func(opts *Options) {
+		opts.Version = version
+	}
+}
+
+// New counts the objects in the DB and compares them to the limits of the
+// APIC release. Per-leaf metrics are reported for every leaf in topSystem and
+// every node with objects of the metric's class.
+func New(db *mit.DB, mods ...func(*Options)) (Report, error) {
+	opts := Options{}
+	for _, mod := range mods {
+		mod(&opts)
+	}
+	report := Report{Version: opts.Version}
+	if report.Version == "" {
+		version, err := Version(db)
+		if err != nil {
+			return report, err
+		}
+		report.Version = version
+	}
+	rel, err := LimitsFor(report.Version)
+	if err != nil {
+		return report, err
+	}
+	report.Release = rel.Name
+	report.Source = rel.Source
+
+	loaded := map[string]bool{}
+	for _, c := range db.Collections() {
+		loaded[c.Class] = true
+	}
+	leaves, err := leafIDs(db)
+	if err != nil {
+		return report, err
+	}
+	for _, m := range Metrics {
+		n, err := db.Count("%s:*", m.Class)
+		if err != nil {
+			return report, err
+		}
+		if n == 0 && !loaded[m.Class] {
+			report.Skipped = append(report.Skipped, m.Name)
+			continue
+		}
+		if m.Scope == Fabric {
+			report.Items = append(report.Items, newItem(m, "", n, rel.Fabric[m.Name]))
+			continue
+		}
+		counts := map[string]int{}
+		for _, id := range leaves {
+			counts[id] = 0
+		}
+		if err := db.FindEach(m.Class+":*", func(_ string, v gjson.Result) bool {
+			if _, node, ok := mit.NodeDN(v.Get("dn").Str); ok {
+				counts[node]++
+			}
+			return true
+		}); err != nil {
+			return report, err
+		}
+		nodes := make([]string, 0, len(counts))
+		for node := range counts {
+			nodes = append(nodes, node)
+		}
+		sort.Slice(nodes, func(i, j int) bool { return mit.LessNumeric(nodes[i], nodes[j]) })
+		for _, node := range nodes {
+			report.Items = append(report.Items, newItem(m, node, counts[node], rel.Leaf[m.Name]))
+		}
+	}
+	return report, nil
+}
+
+// newItem returns the item for a count, with no percentage if the release
+// has no limit for the metric.
+func newItem(m Metric, node string, count, limit int) Item {
+	item := Item{Metric: m.Name, Scope: m.Scope, Node: node, Count: count, Limit: limit}
+	if limit > 0 {
+		item.Percent = float64(count) * 100 / float64(limit)
+	}
+	return item
+}
+
+// leafIDs returns the IDs of the leaves in topSystem.
+func leafIDs(db *mit.DB) ([]string, error) {
+	var res []string
+	err := db.FindEach("topSystem:*", func(_ string, v gjson.Result) bool {
+		if v.Get("role").Str == "leaf" {
+			res = append(res, v.Get("id").Str)
+		}
+		return true
+	})
+	return res, err
+}
@@ -0,0 +1,60 @@
+package scale
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	report, err := New(db)
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal("5.2(7f)", report.Version)
+	a.Equal("5.2", report.Release)
+	a.Equal("Cisco APIC Verified Scalability Guide, Release 5.2(x)", report.Source)
+	a.Equal([]string{"l3outs", "ipEndpoints", "vlans"}, report.Skipped)
+
+	items := map[string]Item{}
+	for _, item := range report.Items {
+		items[item.Metric+":"+item.Node] = item
+	}
+	a.Len(items, 10)
+	a.Equal(Item{Metric: "tenants", Scope: Fabric, Count: 3, Limit: 3000, Percent: 0.1}, items["tenants:"])
+	a.Equal(4, items["epgs:"].Count)
+	a.Equal(21000, items["epgs:"].Limit)
+	a.Equal(Item{Metric: "macEndpoints", Scope: Leaf, Node: "101", Count: 3, Limit: 24000, Percent: 0.0125}, items["macEndpoints:101"])
+	a.Equal(1, items["macEndpoints:102"].Count)
+	a.Equal(5, items["policyCAM:101"].Count)
+	a.Equal(64000, items["policyCAM:101"].Limit)
+	// Leaves without objects are reported
+	a.Equal(0, items["policyCAM:102"].Count)
+	a.NotContains(items, "policyCAM:201")
+
+	over := report.Over(0.05)
+	if a.Len(over, 2) {
+		a.Equal("tenants", over[0].Metric)
+		a.Equal("vrfs", over[1].Metric)
+	}
+}
+
+func TestForVersion(t *testing.T) {
+	a := assert.New(t)
+	db := mittest.Folder(t, "testdata")
+	report, err := New(db, ForVersion("4.2(7w)"))
+	a.NoError(err)
+	a.Equal("4.2", report.Release)
+	for _, item := range report.Items {
+		if item.Metric == "epgs" {
+			a.Equal(15000, item.Limit)
+		}
+	}
+
+	_, err = New(db, ForVersion("3.2(9h)"))
+	a.Error(err)
+}
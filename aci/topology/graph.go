@@ -0,0 +1,131 @@
+package topology
+
+import (
+	"sort"
+
+	"lib/aci/mit"
+)
+
+// Pods returns the pod IDs in the fabric.
+func (t *Topology) Pods() (res []string) {
+	seen := map[string]bool{}
+	for _, n := range t.Nodes {
+		if n.Pod != "" && !seen[n.Pod] {
+			seen[n.Pod] = true
+			res = append(res, n.Pod)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return mit.LessNumeric(res[i], res[j]) })
+	return res
+}
+
+// ByRole returns the nodes with a role sorted by ID.
+func (t *Topology) ByRole(role string) (res []*Node) {
+	for _, n := range t.Nodes {
+		if n.Role == role {
+			res = append(res, n)
+		}
+	}
+	sortNodes(res)
+	return res
+}
+
+// LinksOf returns the links of a node.
+func (t *Topology) LinksOf(id string) (res []*Link) {
+	for _, l := range t.Links {
+		if l.A.Node == id || l.B.Node == id {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// Neighbors returns the fabric nodes linked to a node sorted by ID.
+func (t *Topology) Neighbors(id string) (res []*Node) {
+	seen := map[string]bool{}
+	for _, l := range t.LinksOf(id) {
+		peer := l.Peer(id).Node
+		if peer != "" && peer != id && !seen[peer] {
+			seen[peer] = true
+			res = append(res, t.Nodes[peer])
+		}
+	}
+	sortNodes(res)
+	return res
+}
+
+// Path returns the shortest path of node IDs between two nodes, including
+// both ends, or nil if the nodes are not connected.
+func (t *Topology) Path(from, to string) []string {
+	if _, ok := t.Nodes[from]; !ok {
+		return nil
+	}
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			var path []string
+			for ; id != ""; id = prev[id] {
+				path = append([]string{id}, path...)
+			}
+			return path
+		}
+		for _, n := range t.Neighbors(id) {
+			if _, ok := prev[n.ID]; !ok {
+				prev[n.ID] = id
+				queue = append(queue, n.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Missing is an expected link that is not in the topology.
+type Missing struct {
+	Node string
+	// Peer is the expected peer, or empty if any peer of the role is missing.
+	Peer string
+	Role string
+}
+
+// MissingLinks reports leaves not linked to every spine in their pod, and
+// controllers not linked to any leaf. Remote leaves connect over the IPN and
+// are not checked.
+func (t *Topology) MissingLinks() (res []Missing) {
+	spines := t.ByRole(Spine)
+	for _, leaf := range t.ByRole(Leaf) {
+		linked := t.linked(leaf.ID)
+		for _, spine := range spines {
+			if spine.Pod == leaf.Pod && !linked[spine.ID] {
+				res = append(res, Missing{Node: leaf.ID, Peer: spine.ID, Role: Spine})
+			}
+		}
+	}
+	for _, ctrl := range t.ByRole(Controller) {
+		found := false
+		for id := range t.linked(ctrl.ID) {
+			if n := t.Nodes[id]; n.Role == Leaf || n.Role == RemoteLeaf {
+				found = true
+			}
+		}
+		if !found {
+			res = append(res, Missing{Node: ctrl.ID, Role: Leaf})
+		}
+	}
+	return res
+}
+
+// linked returns the set of fabric neighbors of a node.
+func (t *Topology) linked(id string) map[string]bool {
+	res := map[string]bool{}
+	for _, n := range t.Neighbors(id) {
+		res[n.ID] = true
+	}
+	return res
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool { return mit.LessNumeric(nodes[i].ID, nodes[j].ID) })
+}
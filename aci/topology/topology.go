@@ -0,0 +1,282 @@
+// Package topology models the ACI fabric topology from MOs in a mit.DB.
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"lib/aci/mit"
+
+	"github.com/tidwall/gjson"
+)
+
+// Node roles
+const (
+	Controller = "controller"
+	Leaf       = "leaf"
+	Spine      = "spine"
+	RemoteLeaf = "remote-leaf"
+)
+
+// Node is a fabric node.
+type Node struct {
+	ID   string
+	Pod  string
+	Name string
+	// Role is one of Controller, Leaf, Spine or RemoteLeaf.
+	Role    string
+	Model   string
+	Serial  string
+	Version string
+	// Address is the TEP address and OOB the out-of-band management address.
+	Address string
+	OOB     string
+	DN      string
+}
+
+// Endpoint is one end of a link.
+type Endpoint struct {
+	// Node is the node ID, or empty for devices outside the fabric.
+	Node string
+	Name string
+	Port string
+}
+
+func (e Endpoint) String() string {
+	if e.Node != "" {
+		return fmt.Sprintf("node-%s:%s", e.Node, e.Port)
+	}
+	return e.Name + ":" + e.Port
+}
+
+// Link is a link between two endpoints.
+type Link struct {
+	A, B Endpoint
+	// Sources are the classes the link was learned from, e.g. fabricLink or lldpAdjEp.
+	Sources []string
+	// State is the fabricLink state, if any.
+	State string
+}
+
+// External reports whether the link connects to a device outside the fabric.
+func (l *Link) External() bool {
+	return l.A.Node == "" || l.B.Node == ""
+}
+
+// Peer returns the far end of the link from a node.
+func (l *Link) Peer(id string) Endpoint {
+	if l.A.Node == id {
+		return l.B
+	}
+	return l.A
+}
+
+// Topology is a graph of fabric nodes and links.
+type Topology struct {
+	// Nodes maps node IDs to nodes.
+	Nodes map[string]*Node
+	Links []*Link
+	// links indexes links by endpoint pair
+	links map[string]*Link
+}
+
+// New builds the topology from fabricNode, topSystem, fabricLink, lldpAdjEp
+// and cdpAdjEp MOs. Missing classes are skipped.
+func New(db *mit.DB) (*Topology, error) {
+	t := &Topology{
+		Nodes: map[string]*Node{},
+		links: map[string]*Link{},
+	}
+	for _, step := range []struct {
+		class string
+		fn    func(gjson.Result)
+	}{
+		{"fabricNode", t.addFabricNode},
+		{"topSystem", t.addTopSystem},
+		{"fabricLink", t.addFabricLink},
+		{"lldpAdjEp", t.addLLDP},
+		{"cdpAdjEp", t.addCDP},
+	} {
+		err := db.FindEach(step.class+":*", func(_ string, v gjson.Result) bool {
+			step.fn(v)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(t.Links, func(i, j int) bool {
+		a, b := t.Links[i], t.Links[j]
+		if a.A.String() != b.A.String() {
+			return a.A.String() < b.A.String()
+		}
+		return a.B.String() < b.B.String()
+	})
+	return t, nil
+}
+
+// node returns the node for an ID, creating it if needed.
+func (t *Topology) node(id string) *Node {
+	n, ok := t.Nodes[id]
+	if !ok {
+		n = &Node{ID: id}
+		t.Nodes[id] = n
+	}
+	return n
+}
+
+func (t *Topology) addFabricNode(v gjson.Result) {
+	n := t.node(v.Get("id").Str)
+	n.DN = v.Get("dn").Str
+	n.Pod, _, _ = mit.NodeDN(n.DN)
+	n.Name = v.Get("name").Str
+	n.Role = v.Get("role").Str
+	n.Model = v.Get("model").Str
+	n.Serial = v.Get("serial").Str
+	if v.Get("nodeType").Str == "remote-leaf-wan" {
+		n.Role = RemoteLeaf
+	}
+}
+
+func (t *Topology) addTopSystem(v gjson.Result) {
+	n := t.node(v.Get("id").Str)
+	if n.DN == "" {
+		n.DN = mit.ParentDN(v.Get("dn").Str)
+	}
+	setIf := func(s *string, value string) {
+		if value != "" {
+			*s = value
+		}
+	}
+	setIf(&n.Pod, v.Get("podId").Str)
+	setIf(&n.Name, v.Get("name").Str)
+	setIf(&n.Serial, v.Get("serial").Str)
+	setIf(&n.Version, v.Get("version").Str)
+	setIf(&n.Address, v.Get("address").Str)
+	setIf(&n.OOB, v.Get("oobMgmtAddr").Str)
+	if n.Role == "" {
+		n.Role = v.Get("role").Str
+	}
+	if v.Get("remoteNode").Str == "yes" {
+		n.Role = RemoteLeaf
+	}
+}
+
+func (t *Topology) addFabricLink(v gjson.Result) {
+	end := func(n, s, p string) Endpoint {
+		return Endpoint{
+			Node: v.Get(n).Str,
+			Name: t.node(v.Get(n).Str).Name,
+			Port: fmt.Sprintf("eth%s/%s", v.Get(s).Str, v.Get(p).Str),
+		}
+	}
+	l := t.addLink(end("n1", "s1", "p1"), end("n2", "s2", "p2"), "fabricLink")
+	l.State = v.Get("linkState").Str
+}
+
+func (t *Topology) addLLDP(v gjson.Result) {
+	local, ok := t.local(v.Get("dn").Str)
+	if !ok {
+		return
+	}
+	peer := Endpoint{Name: v.Get("sysName").Str, Port: normalizePort(v.Get("portIdV").Str)}
+	// ACI nodes advertise their DN as the system description
+	if pod, id, ok := mit.NodeDN(v.Get("sysDesc").Str + "/"); ok {
+		// The peer may not be registered, e.g. without fabricNode or topSystem
+		n := t.node(id)
+		if n.DN == "" {
+			n.DN = fmt.Sprintf("topology/pod-%s/node-%s", pod, id)
+			n.Pod = pod
+		}
+		if n.Name == "" {
+			n.Name = peer.Name
+		}
+		peer.Node = id
+	} else {
+		peer.Node = t.lookup(v.Get("mgmtIp").Str, peer.Name)
+	}
+	t.addLink(local, t.named(peer), "lldpAdjEp")
+}
+
+func (t *Topology) addCDP(v gjson.Result) {
+	local, ok := t.local(v.Get("dn").Str)
+	if !ok {
+		return
+	}
+	name := v.Get("devId").Str
+	if i := strings.Index(name, "("); i > 0 {
+		name = name[:i]
+	}
+	peer := Endpoint{Name: name, Port: normalizePort(v.Get("portId").Str)}
+	peer.Node = t.lookup(v.Get("mgmtIp").Str, name)
+	t.addLink(local, t.named(peer), "cdpAdjEp")
+}
+
+// local returns the local endpoint of an adjacency DN, e.g.
+// topology/pod-1/node-101/sys/lldp/inst/if-[eth1/1]/adj-1
+func (t *Topology) local(dn string) (Endpoint, bool) {
+	_, id, ok := mit.NodeDN(dn)
+	if !ok {
+		return Endpoint{}, false
+	}
+	port := mit.SplitDN(mit.ParentDN(dn))
+	e := Endpoint{Node: id, Name: t.node(id).Name}
+	if len(port) > 0 {
+		e.Port = strings.TrimSuffix(strings.TrimPrefix(port[len(port)-1], "if-["), "]")
+	}
+	return e, true
+}
+
+// lookup finds a node ID by management address or name.
+func (t *Topology) lookup(addr, name string) string {
+	short := strings.SplitN(name, ".", 2)[0]
+	for id, n := range t.Nodes {
+		if addr != "" && (addr == n.OOB || addr == n.Address) {
+			return id
+		}
+		if short != "" && short == n.Name {
+			return id
+		}
+	}
+	return ""
+}
+
+// named fills in the fabric node name of an endpoint.
+func (t *Topology) named(e Endpoint) Endpoint {
+	if n, ok := t.Nodes[e.Node]; ok && n.Name != "" {
+		e.Name = n.Name
+	}
+	return e
+}
+
+// addLink adds or merges a link, in either direction.
+func (t *Topology) addLink(a, b Endpoint, source string) *Link {
+	if b.String() < a.String() {
+		a, b = b, a
+	}
+	key := a.String() + "|" + b.String()
+	l, ok := t.links[key]
+	if !ok {
+		l = &Link{A: a, B: b}
+		t.links[key] = l
+		t.Links = append(t.Links, l)
+	}
+	for _, s := range l.Sources {
+		if s == source {
+			return l
+		}
+	}
+	l.Sources = append(l.Sources, source)
+	return l
+}
+
+// normalizePort shortens interface names, e.g. Ethernet1/1 to eth1/1.
+func normalizePort(port string) string {
+	for _, prefix := range []string{"Ethernet", "Eth"} {
+		if strings.HasPrefix(port, prefix) {
+			return "eth" + port[len(prefix):]
+		}
+	}
+	return port
+}
@@ -0,0 +1,123 @@
+package topology
+
+import (
+	"testing"
+
+	"lib/aci/internal/mittest"
+	"lib/aci/mit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTopology(t *testing.T) *Topology {
+	db := mittest.Folder(t, "testdata")
+	topo, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return topo
+}
+
+func TestNodes(t *testing.T) {
+	a := assert.New(t)
+	topo := newTestTopology(t)
+	a.Len(topo.Nodes, 6)
+	a.Equal([]string{"1"}, topo.Pods())
+
+	leaf := topo.Nodes["101"]
+	a.Equal("leaf-101", leaf.Name)
+	a.Equal(Leaf, leaf.Role)
+	a.Equal("1", leaf.Pod)
+	a.Equal("N9K-C93180YC-EX", leaf.Model)
+	a.Equal("10.0.160.66", leaf.Address)
+	a.Equal("n9000-14.2(6d)", leaf.Version)
+	a.Equal("topology/pod-1/node-101", leaf.DN)
+
+	a.Equal(RemoteLeaf, topo.Nodes["301"].Role)
+	var spines []string
+	for _, n := range topo.ByRole(Spine) {
+		spines = append(spines, n.ID)
+	}
+	a.Equal([]string{"201", "202"}, spines)
+	a.Len(topo.ByRole(Controller), 1)
+}
+
+func TestLinks(t *testing.T) {
+	a := assert.New(t)
+	topo := newTestTopology(t)
+	// 3 fabric links, leaf to APIC and leaf to an external switch
+	a.Len(topo.Links, 5)
+
+	links := topo.LinksOf("201")
+	a.Len(links, 2)
+	a.Equal(Endpoint{Node: "101", Name: "leaf-101", Port: "eth1/49"}, links[0].A)
+	a.Equal(Endpoint{Node: "201", Name: "spine-201", Port: "eth1/1"}, links[0].B)
+	a.Equal([]string{"fabricLink", "lldpAdjEp"}, links[0].Sources)
+	a.Equal("ok", links[0].State)
+
+	var external []*Link
+	for _, l := range topo.Links {
+		if l.External() {
+			external = append(external, l)
+		}
+	}
+	a.Len(external, 1)
+	a.Equal(Endpoint{Name: "core-sw1.example.com", Port: "GigabitEthernet1/0/1"}, external[0].Peer("102"))
+	a.Equal([]string{"cdpAdjEp"}, external[0].Sources)
+}
+
+func TestNeighbors(t *testing.T) {
+	a := assert.New(t)
+	topo := newTestTopology(t)
+	var ids []string
+	for _, n := range topo.Neighbors("101") {
+		ids = append(ids, n.ID)
+	}
+	a.Equal([]string{"1", "201", "202"}, ids)
+	a.Empty(topo.Neighbors("301"))
+}
+
+func TestUnregisteredPeer(t *testing.T) {
+	a := assert.New(t)
+	adj := func(port, spine string) string {
+		return `{"lldpAdjEp":{"attributes":{` +
+			`"dn":"topology/pod-1/node-101/sys/lldp/inst/if-[` + port + `]/adj-1",` +
+			`"sysName":"spine-` + spine + `","portIdV":"Ethernet1/1",` +
+			`"sysDesc":"topology/pod-1/node-` + spine + `"}}}`
+	}
+	src := mit.NewMemSource()
+	src.Add("lldpAdjEp", []byte(`{"imdata":[`+adj("eth1/49", "201")+`,`+adj("eth1/50", "202")+`]}`))
+	db, err := mit.New(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	topo, err := New(&db)
+	a.NoError(err)
+
+	neighbors := topo.Neighbors("101")
+	if a.Len(neighbors, 2) {
+		a.Equal("201", neighbors[0].ID)
+		a.Equal("spine-201", neighbors[0].Name)
+		a.Equal("1", neighbors[0].Pod)
+		a.Equal("topology/pod-1/node-201", neighbors[0].DN)
+		a.Equal("202", neighbors[1].ID)
+	}
+	a.Equal([]string{"201", "101", "202"}, topo.Path("201", "202"))
+	a.Empty(topo.MissingLinks())
+}
+
+func TestPath(t *testing.T) {
+	a := assert.New(t)
+	topo := newTestTopology(t)
+	a.Equal([]string{"1", "101", "201", "102"}, topo.Path("1", "102"))
+	a.Equal([]string{"101"}, topo.Path("101", "101"))
+	a.Nil(topo.Path("101", "301"))
+	a.Nil(topo.Path("999", "101"))
+}
+
+func TestMissingLinks(t *testing.T) {
+	a := assert.New(t)
+	topo := newTestTopology(t)
+	a.Equal([]Missing{{Node: "102", Peer: "202", Role: Spine}}, topo.MissingLinks())
+}
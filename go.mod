@@ -6,19 +6,21 @@ require (
 	github.com/brightpuddle/goaci v0.5.1
 	github.com/goccy/go-reflect v1.2.0
 	github.com/gofiber/fiber/v2 v2.52.2
+	github.com/klauspost/compress v1.17.7
 	github.com/rs/zerolog v1.32.0
 	github.com/segmentio/encoding v0.4.0
 	github.com/stretchr/testify v1.7.1
 	github.com/tidwall/buntdb v1.3.0
 	github.com/tidwall/gjson v1.17.1
+	github.com/tidwall/match v1.1.1
 	github.com/tidwall/sjson v1.2.5
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
 require (
 	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/klauspost/compress v1.17.7 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
@@ -28,7 +30,6 @@ require (
 	github.com/segmentio/asm v1.2.0 // indirect
 	github.com/tidwall/btree v1.7.0 // indirect
 	github.com/tidwall/grect v0.1.4 // indirect
-	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/rtred v0.1.2 // indirect
 	github.com/tidwall/tinyqueue v0.1.1 // indirect
@@ -36,5 +37,4 @@ require (
 	github.com/valyala/fasthttp v1.52.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )